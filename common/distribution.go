@@ -0,0 +1,126 @@
+package common
+
+import "sort"
+
+// Piecewise-constant 1D distribution, used to pick items proportional to their weight
+type Distribution1D struct {
+	weights []float32
+	cdf     []float32
+	total   float32
+}
+
+func NewDistribution1D(weights []float32) *Distribution1D {
+	cdf := make([]float32, len(weights)+1)
+	for i, weight := range weights {
+		cdf[i+1] = cdf[i] + weight
+	}
+
+	total := cdf[len(weights)]
+	if total > 0 {
+		for i := range cdf {
+			cdf[i] /= total
+		}
+	}
+
+	return &Distribution1D{
+		weights: weights,
+		cdf:     cdf,
+		total:   total,
+	}
+}
+
+func (d *Distribution1D) Total() float32 {
+	return d.total
+}
+
+// Picks an index with probability proportional to its weight, returns the index and its probability
+func (d *Distribution1D) SampleDiscrete(u float32) (int, float32) {
+	if d.total <= 0 {
+		return -1, 0
+	}
+
+	// first cdf entry greater than u marks the end of the chosen segment
+	idx := sort.Search(len(d.cdf), func(i int) bool {
+		return d.cdf[i] > u
+	}) - 1
+	idx = max(0, min(idx, len(d.weights)-1))
+
+	return idx, d.DiscretePmf(idx)
+}
+
+func (d *Distribution1D) DiscretePmf(idx int) float32 {
+	if d.total <= 0 {
+		return 0
+	}
+	return d.weights[idx] / d.total
+}
+
+// Returns a value in [0, 1) distributed proportional to the piecewise-constant function, its density & the segment
+// containing it
+func (d *Distribution1D) SampleContinuous(u float32) (float32, float32, int) {
+	idx, pmf := d.SampleDiscrete(u)
+	if idx < 0 {
+		return 0, 0, -1
+	}
+
+	// position of u within the chosen segment
+	offset := u - d.cdf[idx]
+	if width := d.cdf[idx+1] - d.cdf[idx]; width > 0 {
+		offset /= width
+	}
+
+	count := float32(len(d.weights))
+	return min((float32(idx)+offset)/count, ONE_MINUS_EPSILON), pmf * count, idx
+}
+
+// Returns the density of SampleContinuous choosing the value x in [0, 1)
+func (d *Distribution1D) ContinuousPdf(x float32) float32 {
+	if d.total <= 0 {
+		return 0
+	}
+
+	idx := max(0, min(int(x*float32(len(d.weights))), len(d.weights)-1))
+	return d.DiscretePmf(idx) * float32(len(d.weights))
+}
+
+// Piecewise-constant 2D distribution over [0, 1)², sampled by picking a row from the marginal & a position within
+// the row from its conditional distribution
+type Distribution2D struct {
+	conditionals []*Distribution1D
+	marginal     *Distribution1D
+}
+
+// Expects the weights row by row
+func NewDistribution2D(weights []float32, width, height int) *Distribution2D {
+	conditionals := make([]*Distribution1D, height)
+	rowWeights := make([]float32, height)
+	for y := range height {
+		conditionals[y] = NewDistribution1D(weights[y*width : (y+1)*width])
+		rowWeights[y] = conditionals[y].Total()
+	}
+
+	return &Distribution2D{
+		conditionals: conditionals,
+		marginal:     NewDistribution1D(rowWeights),
+	}
+}
+
+// Returns a position in [0, 1)² & its density, which is zero if the distribution is empty
+func (d *Distribution2D) Sample(u1, u2 float32) (float32, float32, float32) {
+	y, marginalPdf, row := d.marginal.SampleContinuous(u2)
+	if row < 0 {
+		return 0, 0, 0
+	}
+
+	x, conditionalPdf, _ := d.conditionals[row].SampleContinuous(u1)
+	return x, y, marginalPdf * conditionalPdf
+}
+
+func (d *Distribution2D) Pdf(x, y float32) float32 {
+	if d.marginal.Total() <= 0 {
+		return 0
+	}
+
+	row := max(0, min(int(y*float32(len(d.conditionals))), len(d.conditionals)-1))
+	return d.marginal.ContinuousPdf(y) * d.conditionals[row].ContinuousPdf(x)
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-6
+}
+
+func TestDistribution1DSampleDiscrete(t *testing.T) {
+	distribution := NewDistribution1D([]float32{1, 3, 0, 4})
+
+	tests := []struct {
+		u       float32
+		wantIdx int
+		wantPmf float32
+	}{
+		{0, 0, 0.125},
+		{0.2, 1, 0.375},
+		// segments without weight are never picked
+		{0.5, 3, 0.5},
+		{ONE_MINUS_EPSILON, 3, 0.5},
+	}
+
+	for _, tt := range tests {
+		idx, pmf := distribution.SampleDiscrete(tt.u)
+		if idx != tt.wantIdx || !approxEqual(pmf, tt.wantPmf) {
+			t.Errorf("SampleDiscrete(%f) = (%d, %f), want (%d, %f)", tt.u, idx, pmf, tt.wantIdx, tt.wantPmf)
+		}
+		if !approxEqual(distribution.DiscretePmf(idx), pmf) {
+			t.Errorf("DiscretePmf(%d) = %f, want %f", idx, distribution.DiscretePmf(idx), pmf)
+		}
+	}
+}
+
+func TestDistribution1DSampleContinuous(t *testing.T) {
+	distribution := NewDistribution1D([]float32{1, 3})
+
+	tests := []struct {
+		u       float32
+		wantX   float32
+		wantPdf float32
+		wantIdx int
+	}{
+		{0, 0, 0.5, 0},
+		{0.0625, 0.125, 0.5, 0},
+		{0.625, 0.75, 1.5, 1},
+	}
+
+	for _, tt := range tests {
+		x, pdf, idx := distribution.SampleContinuous(tt.u)
+		if !approxEqual(x, tt.wantX) || !approxEqual(pdf, tt.wantPdf) || idx != tt.wantIdx {
+			t.Errorf("SampleContinuous(%f) = (%f, %f, %d), want (%f, %f, %d)", tt.u, x, pdf, idx, tt.wantX, tt.wantPdf, tt.wantIdx)
+		}
+		if !approxEqual(distribution.ContinuousPdf(x), pdf) {
+			t.Errorf("ContinuousPdf(%f) = %f, want %f", x, distribution.ContinuousPdf(x), pdf)
+		}
+	}
+}
+
+func TestDistribution1DWithoutWeight(t *testing.T) {
+	distribution := NewDistribution1D([]float32{0, 0})
+
+	if idx, pmf := distribution.SampleDiscrete(0.5); idx != -1 || pmf != 0 {
+		t.Errorf("SampleDiscrete(0.5) = (%d, %f), want (-1, 0)", idx, pmf)
+	}
+	if _, pdf, idx := distribution.SampleContinuous(0.5); idx != -1 || pdf != 0 {
+		t.Errorf("SampleContinuous(0.5) = (_, %f, %d), want (_, 0, -1)", pdf, idx)
+	}
+	if pdf := distribution.ContinuousPdf(0.5); pdf != 0 {
+		t.Errorf("ContinuousPdf(0.5) = %f, want 0", pdf)
+	}
+}
+
+func TestDistribution2D(t *testing.T) {
+	// 2x2 grid, row by row
+	distribution := NewDistribution2D([]float32{1, 1, 0, 2}, 2, 2)
+
+	tests := []struct {
+		u1, u2       float32
+		wantX, wantY float32
+		wantPdf      float32
+	}{
+		{0.25, 0.25, 0.25, 0.25, 1},
+		{0.75, 0.25, 0.75, 0.25, 1},
+		{0.5, 0.75, 0.75, 0.75, 2},
+	}
+
+	for _, tt := range tests {
+		x, y, pdf := distribution.Sample(tt.u1, tt.u2)
+		if !approxEqual(x, tt.wantX) || !approxEqual(y, tt.wantY) || !approxEqual(pdf, tt.wantPdf) {
+			t.Errorf("Sample(%f, %f) = (%f, %f, %f), want (%f, %f, %f)", tt.u1, tt.u2, x, y, pdf, tt.wantX, tt.wantY, tt.wantPdf)
+		}
+		if !approxEqual(distribution.Pdf(x, y), pdf) {
+			t.Errorf("Pdf(%f, %f) = %f, want %f", x, y, distribution.Pdf(x, y), pdf)
+		}
+	}
+
+	if pdf := distribution.Pdf(0.25, 0.75); pdf != 0 {
+		t.Errorf("Pdf of a cell without weight = %f, want 0", pdf)
+	}
+}
+
+func TestDistribution2DWithoutWeight(t *testing.T) {
+	distribution := NewDistribution2D(make([]float32, 4), 2, 2)
+
+	if _, _, pdf := distribution.Sample(0.5, 0.5); pdf != 0 {
+		t.Errorf("Sample(0.5, 0.5) has density %f, want 0", pdf)
+	}
+	if pdf := distribution.Pdf(0.5, 0.5); pdf != 0 {
+		t.Errorf("Pdf(0.5, 0.5) = %f, want 0", pdf)
+	}
+}
@@ -4,6 +4,9 @@ import "math"
 
 var F32_INF = float32(math.Inf(1))
 
+// Largest float32 below 1, used to keep sample values in [0, 1)
+const ONE_MINUS_EPSILON float32 = 0x1.fffffep-1
+
 func Recip(value float32) float32 {
 	return 1 / value
 }
@@ -11,3 +14,7 @@ func Recip(value float32) float32 {
 func Pow(base, exp float32) float32 {
 	return float32(math.Pow(float64(base), float64(exp)))
 }
+
+func Abs(value float32) float32 {
+	return float32(math.Abs(float64(value)))
+}
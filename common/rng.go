@@ -0,0 +1,46 @@
+package common
+
+// PCG32 random number generator, see https://www.pcg-random.org
+type Rng struct {
+	state uint64
+	inc   uint64
+}
+
+func NewRng() *Rng {
+	rng := &Rng{}
+	rng.Reset(0, 0, 0)
+	return rng
+}
+
+// Deterministically derives a new stream from the seed and the given key & index, e.g. a pixel and its sample number
+func (r *Rng) Reset(seed, key, index uint64) {
+	r.state = 0
+	r.inc = (MixBits(seed^MixBits(key)) << 1) | 1
+	r.Uint32()
+	r.state += MixBits(seed ^ MixBits(key^MixBits(index)))
+	r.Uint32()
+}
+
+func (r *Rng) Uint32() uint32 {
+	oldState := r.state
+	r.state = oldState*6364136223846793005 + r.inc
+
+	xorShifted := uint32(((oldState >> 18) ^ oldState) >> 27)
+	rot := uint32(oldState >> 59)
+	return (xorShifted >> rot) | (xorShifted << ((-rot) & 31))
+}
+
+// Returns a uniformly distributed value in [0, 1)
+func (r *Rng) Float32() float32 {
+	return float32(r.Uint32()>>8) * 0x1p-24
+}
+
+// SplitMix64 finalizer, scrambles the bits of the input
+func MixBits(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
@@ -1,11 +1,23 @@
 package config
 
 const EPSILON = 1e-5
-const MAX_DEPTH = 5.0
+const DEFAULT_MAX_DEPTH = 64
+const DEFAULT_RUSSIAN_ROULETTE_DEPTH = 3
 const BVH_SPACES = 50
 const SAMPLES = 256
+const MIN_SAMPLES = 16
+const ADAPTIVE_ERROR_THRESHOLD = 0.01
+const DEFAULT_FILTER = "gaussian"
+const DEFAULT_FILTER_RADIUS = 1.5
+const DEFAULT_TILE_SIZE = 32
+const DEFAULT_TILE_ORDER = "spiral"
+const DEFAULT_SEED = 0
+const DEFAULT_SAMPLER = "sobol"
+const DEFAULT_SUN_ELEVATION = 45
+const DEFAULT_SUN_AZIMUTH = 0
+const DEFAULT_TURBIDITY = 3
+const DEFAULT_GROUND_ALBEDO = 0.3
 
-const DEPTH_COLOR_DEGRADING_FACTOR = 0.9
 const DEPTH_LIGHT_A_FACTOR = 0.9
 const DEPTH_LIGHT_B_FACTOR = 0.1
 const DEPTH_LIGHT_C_FACTOR = 0.1
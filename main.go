@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"image"
 	"image/jpeg"
+	"image/png"
 	"log"
+	"math"
 	"os"
 	"time"
 
 	"github.com/ruegerj/raytracing/config"
 	"github.com/ruegerj/raytracing/render"
+	"github.com/ruegerj/raytracing/scene"
 	"github.com/ruegerj/raytracing/scene/imprt"
 )
 
@@ -20,23 +23,131 @@ func main() {
 	// defer profile.Start(profile.MemProfile, profile.ProfilePath(".")).Stop()
 
 	pathArg := flag.String("path", "", "path to a .gltf file to import")
+	filterArg := flag.String("filter", config.DEFAULT_FILTER, "reconstruction filter: box, tent, gaussian, mitchell or lanczos")
+	filterRadiusArg := flag.Float64("filter-radius", config.DEFAULT_FILTER_RADIUS, "radius of the reconstruction filter in pixels")
+	tileSizeArg := flag.Int("tile-size", config.DEFAULT_TILE_SIZE, "edge length of the square render tiles in pixels")
+	tileOrderArg := flag.String("tile-order", config.DEFAULT_TILE_ORDER, "order in which tiles are rendered: spiral or hilbert")
+	seedArg := flag.Uint64("seed", config.DEFAULT_SEED, "seed of the random number generation, same seed yields the same image")
+	samplerArg := flag.String("sampler", config.DEFAULT_SAMPLER, "sample generator: independent, stratified, halton or sobol")
+	minSamplesArg := flag.Int("min-samples", config.MIN_SAMPLES, "minimal samples per pixel before adaptive sampling may stop")
+	maxSamplesArg := flag.Int("max-samples", config.SAMPLES, "maximal samples per pixel")
+	errorThresholdArg := flag.Float64("error-threshold", config.ADAPTIVE_ERROR_THRESHOLD, "relative error at which a pixel stops sampling, 0 disables adaptive sampling")
+	maxDepthArg := flag.Int("max-depth", config.DEFAULT_MAX_DEPTH, "maximal number of bounces per path")
+	russianRouletteDepthArg := flag.Int("rr-depth", config.DEFAULT_RUSSIAN_ROULETTE_DEPTH, "number of bounces before paths may be terminated by russian roulette")
+	previewArg := flag.Bool("preview", false, "fast preview using direct & ambient lighting only")
+	heatmapArg := flag.String("heatmap", "", "optional path of a .png file receiving the per pixel sample counts")
+	envArg := flag.String("env", "", "optional equirectangular .hdr or .pfm environment map lighting the scene")
+	envRotationArg := flag.Float64("env-rotation", 0, "rotation of the environment map around the up axis in degrees")
+	envIntensityArg := flag.Float64("env-intensity", 1, "factor scaling the radiance of the environment map")
+	skyArg := flag.Bool("sky", false, "light the scene by an analytic sun & sky, the sun is placed by the first directional light of the scene unless its angles are given")
+	sunElevationArg := flag.Float64("sun-elevation", config.DEFAULT_SUN_ELEVATION, "elevation of the sun above the horizon in degrees")
+	sunAzimuthArg := flag.Float64("sun-azimuth", config.DEFAULT_SUN_AZIMUTH, "azimuth of the sun in degrees, 0 faces -Z & 90 faces +X")
+	turbidityArg := flag.Float64("turbidity", config.DEFAULT_TURBIDITY, "haziness of the sky from 1.7 (clear) to 10 (hazy)")
+	groundAlbedoArg := flag.Float64("ground-albedo", config.DEFAULT_GROUND_ALBEDO, "reflectance of the ground below the horizon")
+	skyIntensityArg := flag.Float64("sky-intensity", 1, "factor scaling the radiance of the sun & sky")
 	flag.Parse()
 	if pathArg == nil || *pathArg == "" {
 		fmt.Println("Please provide a valid path...")
 		os.Exit(1)
 	}
 
+	filter, err := render.NewFilter(*filterArg, float32(*filterRadiusArg))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	tileOrder, err := render.ParseTileOrder(*tileOrderArg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *minSamplesArg <= 0 || *maxSamplesArg < *minSamplesArg {
+		fmt.Println("Please provide positive sample counts with min <= max...")
+		os.Exit(1)
+	}
+
+	sampler, err := render.NewSampler(*samplerArg, *seedArg, *maxSamplesArg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *maxDepthArg <= 0 || *russianRouletteDepthArg < 0 {
+		fmt.Println("Please provide a positive max depth and a non-negative russian roulette depth...")
+		os.Exit(1)
+	}
+
+	if *tileSizeArg <= 0 {
+		fmt.Println("Please provide a positive tile size...")
+		os.Exit(1)
+	}
+
+	if *skyArg && *envArg != "" {
+		fmt.Println("Please provide either an environment map or the sky...")
+		os.Exit(1)
+	}
+
+	if *turbidityArg < 1.7 || *turbidityArg > 10 || *groundAlbedoArg < 0 || *groundAlbedoArg > 1 {
+		fmt.Println("Please provide a turbidity between 1.7 and 10 and a ground albedo between 0 and 1...")
+		os.Exit(1)
+	}
+
+	if *sunElevationArg < 0 || *sunElevationArg > 90 {
+		fmt.Println("Please provide a sun elevation between 0 and 90 degrees...")
+		os.Exit(1)
+	}
+
 	log.Printf("importing %s...\n", *pathArg)
 	img := image.NewRGBA(image.Rect(0, 0, int(config.WIDTH), int(config.HEIGHT)))
 
+	imprt.InitGltfEtensions()
 	world, err := imprt.FromGLTF(*pathArg)
 	if err != nil {
 		panic(err)
 	}
 
+	if *envArg != "" {
+		environment, err := imprt.LoadEnvironment(*envArg, float32(*envRotationArg*math.Pi/180), float32(*envIntensityArg))
+		if err != nil {
+			panic(err)
+		}
+		world.AddInfiniteLight(environment)
+	}
+
+	if *skyArg {
+		sunDirection := scene.SunDirection(float32(*sunElevationArg*math.Pi/180), float32(*sunAzimuthArg*math.Pi/180))
+		// a directional light of the scene only places the sun if no angles are given explicitly
+		if direction := world.SunDirection(); direction != nil && !isFlagSet("sun-elevation") && !isFlagSet("sun-azimuth") {
+			sunDirection = *direction
+		}
+
+		sky, sun := scene.NewSky(sunDirection, float32(*turbidityArg), float32(*groundAlbedoArg), float32(*skyIntensityArg))
+		world.AddInfiniteLight(sky)
+		world.AddInfiniteLight(sun)
+	}
+
+	var heatmap *image.RGBA
+	if *heatmapArg != "" {
+		heatmap = image.NewRGBA(img.Bounds())
+	}
+
 	log.Println("imported world from: ", *pathArg)
 	start := time.Now()
-	render.Do(world, img)
+	render.Do(world, img, render.Options{
+		Filter:               filter,
+		TileSize:             *tileSizeArg,
+		TileOrder:            tileOrder,
+		Sampler:              sampler,
+		MinSamples:           *minSamplesArg,
+		MaxSamples:           *maxSamplesArg,
+		ErrorThreshold:       float32(*errorThresholdArg),
+		Heatmap:              heatmap,
+		Preview:              *previewArg,
+		MaxDepth:             *maxDepthArg,
+		RussianRouletteDepth: *russianRouletteDepthArg,
+	})
 	end := time.Now()
 	log.Printf("total render time: %dms\n", end.UnixMilli()-start.UnixMilli())
 
@@ -46,4 +157,23 @@ func main() {
 	}
 	defer f.Close()
 	jpeg.Encode(f, img, nil)
+
+	if heatmap != nil {
+		heatmapFile, err := os.Create(*heatmapArg)
+		if err != nil {
+			panic(err)
+		}
+		defer heatmapFile.Close()
+		png.Encode(heatmapFile, heatmap)
+	}
+}
+
+func isFlagSet(name string) bool {
+	isSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			isSet = true
+		}
+	})
+	return isSet
 }
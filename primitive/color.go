@@ -70,6 +70,19 @@ func (sc ScalarColor) DivScalar(t float32) ScalarColor {
 	}
 }
 
+// Relative luminance using the Rec. 709 primaries
+func (sc ScalarColor) Luminance() float32 {
+	return 0.2126*sc.R + 0.7152*sc.G + 0.0722*sc.B
+}
+
+func (sc ScalarColor) MaxComponent() float32 {
+	return max(sc.R, sc.G, sc.B)
+}
+
+func (sc ScalarColor) IsBlack() bool {
+	return sc.R == 0 && sc.G == 0 && sc.B == 0
+}
+
 func (sc ScalarColor) Clamp() ScalarColor {
 	return ScalarColor{
 		R: clamp(sc.R),
@@ -119,9 +132,9 @@ func FromRGBAToScalar(base color.RGBA) ScalarColor {
 
 func FromSlice(slice [3]float64) ScalarColor {
 	return ScalarColor{
-		R: clamp(float32(slice[0])),
-		G: clamp(float32(slice[1])),
-		B: clamp(float32(slice[2])),
+		R: float32(slice[0]),
+		G: float32(slice[1]),
+		B: float32(slice[2]),
 	}
 }
 
@@ -0,0 +1,29 @@
+package primitive
+
+import "math"
+
+// Orthonormal basis, used to move directions between world and a local space where Z is the normal
+type Frame struct {
+	X, Y, Z Vec3
+}
+
+// Builds a frame around the given normalized vector (Duff et al., "Building an Orthonormal Basis, Revisited")
+func NewFrame(z Vec3) Frame {
+	sign := float32(math.Copysign(1, float64(z.Z)))
+	a := -1 / (sign + z.Z)
+	b := z.X * z.Y * a
+
+	return Frame{
+		X: Vec3{X: 1 + sign*z.X*z.X*a, Y: sign * b, Z: -sign * z.X},
+		Y: Vec3{X: b, Y: sign + z.Y*z.Y*a, Z: -z.Y},
+		Z: z,
+	}
+}
+
+func (f Frame) ToLocal(v Vec3) Vec3 {
+	return Vec3{X: v.Dot(f.X), Y: v.Dot(f.Y), Z: v.Dot(f.Z)}
+}
+
+func (f Frame) FromLocal(v Vec3) Vec3 {
+	return f.X.MulScalar(v.X).Add(f.Y.MulScalar(v.Y)).Add(f.Z.MulScalar(v.Z))
+}
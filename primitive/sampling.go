@@ -0,0 +1,67 @@
+package primitive
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
+)
+
+// Warps a uniform sample onto the unit disk, using the concentric mapping of Shirley & Chiu to preserve stratification
+func SampleUniformDiskConcentric(u Vec2) Vec2 {
+	offsetX := 2*u.X - 1
+	offsetY := 2*u.Y - 1
+	if offsetX == 0 && offsetY == 0 {
+		return Vec2{}
+	}
+
+	var r, theta float32
+	if common.Abs(offsetX) > common.Abs(offsetY) {
+		r = offsetX
+		theta = math.Pi / 4 * (offsetY / offsetX)
+	} else {
+		r = offsetY
+		theta = math.Pi/2 - math.Pi/4*(offsetX/offsetY)
+	}
+
+	return Vec2{
+		X: r * float32(math.Cos(float64(theta))),
+		Y: r * float32(math.Sin(float64(theta))),
+	}
+}
+
+// Returns a direction in the local hemisphere around +Z, distributed proportional to the cosine of the polar angle
+func SampleCosineHemisphere(u Vec2) Vec3 {
+	d := SampleUniformDiskConcentric(u)
+	z := float32(math.Sqrt(float64(max(0, 1-d.X*d.X-d.Y*d.Y))))
+	return Vec3{X: d.X, Y: d.Y, Z: z}
+}
+
+func CosineHemispherePdf(cosTheta float32) float32 {
+	return cosTheta / math.Pi
+}
+
+// Returns a uniformly distributed direction in the local hemisphere around +Z
+func SampleUniformHemisphere(u Vec2) Vec3 {
+	z := u.X
+	r := float32(math.Sqrt(float64(max(0, 1-z*z))))
+	phi := 2 * math.Pi * u.Y
+	return Vec3{
+		X: r * float32(math.Cos(float64(phi))),
+		Y: r * float32(math.Sin(float64(phi))),
+		Z: z,
+	}
+}
+
+// Returns uniformly distributed barycentric coordinates of a point on a triangle
+func SampleUniformTriangle(u Vec2) Vec3 {
+	var b0, b1 float32
+	if u.X < u.Y {
+		b0 = u.X / 2
+		b1 = u.Y - b0
+	} else {
+		b1 = u.Y / 2
+		b0 = u.X - b1
+	}
+
+	return Vec3{X: b0, Y: b1, Z: 1 - b0 - b1}
+}
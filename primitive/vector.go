@@ -2,7 +2,6 @@ package primitive
 
 import (
 	"math"
-	"math/rand"
 )
 
 type Vec3 struct {
@@ -155,26 +154,3 @@ func (v Vec2) MulScalar(t float32) Vec2 {
 		Y: v.Y * t,
 	}
 }
-
-func RandomOnHemisphere(normal Vec3) Vec3 {
-	vecOnUnitSphere := RandomUnitVector()
-	if vecOnUnitSphere.Dot(normal) > 0.0 {
-		return vecOnUnitSphere
-	}
-
-	return vecOnUnitSphere.Negate()
-}
-
-func RandomUnitVector() Vec3 {
-	for {
-		p := Vec3{signedRand(), signedRand(), signedRand()}
-		lenSquared := p.LengthSquared()
-		if 1e-30 < lenSquared && lenSquared <= 1.0 {
-			return p.DivScalar(float32(math.Sqrt(float64(lenSquared))))
-		}
-	}
-}
-
-func signedRand() float32 {
-	return rand.Float32()*2.0 - 1.0
-}
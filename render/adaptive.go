@@ -0,0 +1,56 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Running mean & variance of the sample luminance of a pixel (Welford's algorithm)
+type pixelStatistics struct {
+	count int
+	mean  float64
+	m2    float64
+}
+
+func (ps *pixelStatistics) add(sample primitive.ScalarColor) {
+	value := float64(sample.Luminance())
+	ps.count++
+	delta := value - ps.mean
+	ps.mean += delta / float64(ps.count)
+	ps.m2 += delta * (value - ps.mean)
+}
+
+// Checks whether the standard error of the mean, relative to the mean itself, fell below the threshold
+func (ps *pixelStatistics) converged(threshold float32) bool {
+	if ps.count < 2 {
+		return false
+	}
+
+	variance := ps.m2 / float64(ps.count-1)
+	standardError := math.Sqrt(variance / float64(ps.count))
+	// dark pixels would need excessive samples for a tight relative error while their noise is hardly visible
+	relativeError := standardError / max(ps.mean, 1e-2)
+
+	return relativeError < float64(threshold)
+}
+
+// Visualizes the samples taken per pixel, ranging from black (min samples) over red & yellow to white (max samples)
+func writeHeatmap(sampleCounts [][]int, minSamples, maxSamples int, img *image.RGBA) {
+	sampleRange := float32(max(1, maxSamples-minSamples))
+
+	for y := range sampleCounts {
+		for x, count := range sampleCounts[y] {
+			t := 3 * float32(count-minSamples) / sampleRange
+			heat := primitive.ScalarColor{R: t, G: t - 1, B: t - 2}.Clamp()
+			img.Set(x, y, color.RGBA{
+				R: uint8(heat.R * 255),
+				G: uint8(heat.G * 255),
+				B: uint8(heat.B * 255),
+				A: 255,
+			})
+		}
+	}
+}
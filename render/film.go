@@ -0,0 +1,160 @@
+package render
+
+import (
+	"image"
+	"math"
+	"sync"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Pixels whose filter weights sum up to less are left black, as the negative lobes of some filters may almost cancel
+// out the weight of the few samples reaching a pixel & blow up or flip its color
+const min_weight_sum float32 = 1e-6
+
+type filmPixel struct {
+	weightedSum primitive.ScalarColor
+	weightSum   float32
+}
+
+func (p *filmPixel) add(o filmPixel) {
+	p.weightedSum = p.weightedSum.Add(o.weightedSum)
+	p.weightSum += o.weightSum
+}
+
+// Accumulates the filtered samples of all tiles into the final image
+type Film struct {
+	width  int
+	height int
+	filter Filter
+	pixels [][]filmPixel
+	// each pixel is rendered by exactly one worker, hence the counts are written without locking
+	sampleCounts [][]int
+	lock         sync.Mutex
+	// finished tiles waiting for their predecessors to be merged
+	pendingTiles map[int]*FilmTile
+	nextTileIdx  int
+	tilePool     sync.Pool
+}
+
+func NewFilm(width, height int, filter Filter) *Film {
+	pixels := make([][]filmPixel, height)
+	sampleCounts := make([][]int, height)
+	for y := range pixels {
+		pixels[y] = make([]filmPixel, width)
+		sampleCounts[y] = make([]int, width)
+	}
+
+	return &Film{
+		width:  width,
+		height: height,
+		filter: filter,
+		pixels: pixels,
+
+		sampleCounts: sampleCounts,
+		pendingTiles: make(map[int]*FilmTile),
+	}
+}
+
+// Returns a cleared buffer for the given tile, reusing the ones of already merged tiles
+func (f *Film) AcquireTile(t tile) *FilmTile {
+	ft, ok := f.tilePool.Get().(*FilmTile)
+	if !ok {
+		ft = &FilmTile{film: f}
+	}
+
+	ft.reset(t)
+	return ft
+}
+
+// Merges the tiles strictly in the order of their index, so the floating point sums don't depend on the scheduling
+func (f *Film) Merge(tileIdx int, ft *FilmTile) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.pendingTiles[tileIdx] = ft
+
+	for {
+		next, ok := f.pendingTiles[f.nextTileIdx]
+		if !ok {
+			return
+		}
+
+		delete(f.pendingTiles, f.nextTileIdx)
+		f.nextTileIdx++
+		f.mergeTile(next)
+		f.tilePool.Put(next)
+	}
+}
+
+func (f *Film) mergeTile(ft *FilmTile) {
+	for y := ft.minY; y < ft.maxY; y++ {
+		row := ft.pixels[(y-ft.minY)*ft.width : (y-ft.minY+1)*ft.width]
+		for x, pixel := range row {
+			f.pixels[y][ft.minX+x].add(pixel)
+		}
+	}
+}
+
+func (f *Film) WriteTo(img *image.RGBA) {
+	for y := range f.pixels {
+		for x, pixel := range f.pixels[y] {
+			color := primitive.BLACK
+			if pixel.weightSum > min_weight_sum {
+				color = pixel.weightedSum.DivScalar(pixel.weightSum)
+			}
+			img.Set(x, y, color.Clamp().GammaCorrect().ToRGBA())
+		}
+	}
+}
+
+// Local accumulation buffer of a single tile, enlarged by the filter radius since samples splat onto neighbouring pixels
+type FilmTile struct {
+	film       *Film
+	minX, minY int
+	maxX, maxY int
+	width      int
+	pixels     []filmPixel
+}
+
+// Prepares the buffer for the given tile, reusing the previous allocation when possible
+func (ft *FilmTile) reset(t tile) {
+	radius := ft.film.filter.Radius()
+
+	ft.minX = max(0, int(math.Ceil(float64(float32(t.minX)-0.5-radius))))
+	ft.minY = max(0, int(math.Ceil(float64(float32(t.minY)-0.5-radius))))
+	ft.maxX = min(ft.film.width, int(math.Floor(float64(float32(t.maxX)-0.5+radius)))+1)
+	ft.maxY = min(ft.film.height, int(math.Floor(float64(float32(t.maxY)-0.5+radius)))+1)
+	ft.width = ft.maxX - ft.minX
+
+	size := ft.width * (ft.maxY - ft.minY)
+	if cap(ft.pixels) < size {
+		ft.pixels = make([]filmPixel, size)
+	}
+	ft.pixels = ft.pixels[:size]
+	clear(ft.pixels)
+}
+
+// Adds a sample taken at the continuous film position (x, y), pixel (i, j) covers [i, i+1) x [j, j+1)
+func (ft *FilmTile) AddSample(x, y float32, color primitive.ScalarColor) {
+	filter := ft.film.filter
+	radius := filter.Radius()
+
+	minX := max(ft.minX, int(math.Ceil(float64(x-0.5-radius))))
+	maxX := min(ft.maxX-1, int(math.Floor(float64(x-0.5+radius))))
+	minY := max(ft.minY, int(math.Ceil(float64(y-0.5-radius))))
+	maxY := min(ft.maxY-1, int(math.Floor(float64(y-0.5+radius))))
+
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			weight := filter.Evaluate(float32(px)+0.5-x, float32(py)+0.5-y)
+			if weight == 0 {
+				continue
+			}
+
+			pixel := &ft.pixels[(py-ft.minY)*ft.width+px-ft.minX]
+			pixel.weightedSum = pixel.weightedSum.Add(color.MulScalar(weight))
+			pixel.weightSum += weight
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package render
+
+import (
+	"image"
+	"testing"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+func TestFilmWriteToNormalizesByWeight(t *testing.T) {
+	tests := []struct {
+		name  string
+		pixel filmPixel
+		want  primitive.ScalarColor
+	}{
+		{"positive weight", filmPixel{weightedSum: primitive.ScalarColor{R: 1, G: 0.5, B: 0}, weightSum: 2}, primitive.ScalarColor{R: 0.5, G: 0.25}},
+		{"no samples", filmPixel{}, primitive.BLACK},
+		// left by the negative lobes of a filter
+		{"negative weight", filmPixel{weightedSum: primitive.ScalarColor{R: -0.5, G: -0.5, B: -0.5}, weightSum: -0.5}, primitive.BLACK},
+		{"vanishing weight", filmPixel{weightedSum: primitive.ScalarColor{R: 1e-8, G: 1e-8, B: 1e-8}, weightSum: 1e-8}, primitive.BLACK},
+	}
+
+	for _, tt := range tests {
+		film := NewFilm(1, 1, NewBoxFilter(0.5))
+		film.pixels[0][0] = tt.pixel
+
+		img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		film.WriteTo(img)
+		want := tt.want.GammaCorrect().ToRGBA()
+		if got := img.At(0, 0); got != want {
+			t.Errorf("%s: pixel = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
+)
+
+// Reconstruction filter, weighting a sample by its offset to the pixel center
+type Filter interface {
+	Radius() float32
+	Evaluate(x, y float32) float32
+}
+
+func NewFilter(name string, radius float32) (Filter, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("filter radius must be positive, got: %f", radius)
+	}
+
+	switch name {
+	case "box":
+		return NewBoxFilter(radius), nil
+	case "tent":
+		return NewTentFilter(radius), nil
+	case "gaussian":
+		return NewGaussianFilter(radius), nil
+	case "mitchell":
+		return NewMitchellFilter(radius), nil
+	case "lanczos":
+		return NewLanczosFilter(radius), nil
+	default:
+		return nil, fmt.Errorf("unknown filter: %s", name)
+	}
+}
+
+var _ Filter = (*BoxFilter)(nil)
+
+type BoxFilter struct {
+	radius float32
+}
+
+func NewBoxFilter(radius float32) *BoxFilter {
+	return &BoxFilter{radius: radius}
+}
+
+func (f *BoxFilter) Radius() float32 {
+	return f.radius
+}
+
+func (f *BoxFilter) Evaluate(x, y float32) float32 {
+	if common.Abs(x) > f.radius || common.Abs(y) > f.radius {
+		return 0
+	}
+	return 1
+}
+
+var _ Filter = (*TentFilter)(nil)
+
+type TentFilter struct {
+	radius float32
+}
+
+func NewTentFilter(radius float32) *TentFilter {
+	return &TentFilter{radius: radius}
+}
+
+func (f *TentFilter) Radius() float32 {
+	return f.radius
+}
+
+func (f *TentFilter) Evaluate(x, y float32) float32 {
+	return max(0, f.radius-common.Abs(x)) * max(0, f.radius-common.Abs(y))
+}
+
+var _ Filter = (*GaussianFilter)(nil)
+
+type GaussianFilter struct {
+	radius float32
+	sigma  float32
+	// value at the radius, subtracted so the filter smoothly falls off to zero
+	edge float32
+}
+
+func NewGaussianFilter(radius float32) *GaussianFilter {
+	sigma := radius / 3
+	return &GaussianFilter{
+		radius: radius,
+		sigma:  sigma,
+		edge:   gaussian(radius, sigma),
+	}
+}
+
+func (f *GaussianFilter) Radius() float32 {
+	return f.radius
+}
+
+func (f *GaussianFilter) Evaluate(x, y float32) float32 {
+	return max(0, gaussian(x, f.sigma)-f.edge) * max(0, gaussian(y, f.sigma)-f.edge)
+}
+
+var _ Filter = (*MitchellFilter)(nil)
+
+// Mitchell-Netravali filter with the recommended parameters B = C = 1/3
+type MitchellFilter struct {
+	radius float32
+	b      float32
+	c      float32
+}
+
+func NewMitchellFilter(radius float32) *MitchellFilter {
+	return &MitchellFilter{
+		radius: radius,
+		b:      1.0 / 3.0,
+		c:      1.0 / 3.0,
+	}
+}
+
+func (f *MitchellFilter) Radius() float32 {
+	return f.radius
+}
+
+func (f *MitchellFilter) Evaluate(x, y float32) float32 {
+	return f.mitchell1D(2*x/f.radius) * f.mitchell1D(2*y/f.radius)
+}
+
+func (f *MitchellFilter) mitchell1D(x float32) float32 {
+	x = common.Abs(x)
+	b := f.b
+	c := f.c
+
+	if x <= 1 {
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	}
+	if x <= 2 {
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return 0
+}
+
+var _ Filter = (*LanczosFilter)(nil)
+
+// Sinc filter windowed by a wider sinc lobe, using as many lobes as the radius
+type LanczosFilter struct {
+	radius float32
+}
+
+func NewLanczosFilter(radius float32) *LanczosFilter {
+	return &LanczosFilter{radius: radius}
+}
+
+func (f *LanczosFilter) Radius() float32 {
+	return f.radius
+}
+
+func (f *LanczosFilter) Evaluate(x, y float32) float32 {
+	return f.lanczos1D(x) * f.lanczos1D(y)
+}
+
+func (f *LanczosFilter) lanczos1D(x float32) float32 {
+	if common.Abs(x) > f.radius {
+		return 0
+	}
+	return sinc(x) * sinc(x/f.radius)
+}
+
+func gaussian(x, sigma float32) float32 {
+	return float32(math.Exp(float64(-x * x / (2 * sigma * sigma))))
+}
+
+func sinc(x float32) float32 {
+	if common.Abs(x) < 1e-5 {
+		return 1
+	}
+	piX := math.Pi * float64(x)
+	return float32(math.Sin(piX) / piX)
+}
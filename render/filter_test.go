@@ -0,0 +1,66 @@
+package render
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFilterEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		radius float32
+		x, y   float32
+		want   float32
+	}{
+		{"box", 0.5, 0, 0, 1},
+		{"box", 0.5, 0.4, -0.4, 1},
+		{"box", 0.5, 0.6, 0, 0},
+		{"tent", 2, 0, 0, 4},
+		{"tent", 2, 1, 0, 2},
+		{"tent", 2, -1, 1, 1},
+		{"tent", 2, 2.5, 0, 0},
+		{"gaussian", 1.5, 0, 0, 0.977905},
+		{"gaussian", 1.5, 0.5, 0, 0.588808},
+		{"gaussian", 1.5, 1.5, 0, 0},
+		{"mitchell", 2, 0, 0, 0.790123},
+		{"mitchell", 2, 1, 0, 0.049383},
+		{"mitchell", 2, 0, -1.5, -0.030864},
+		{"mitchell", 2, 2, 0, 0},
+		{"lanczos", 3, 0, 0, 1},
+		{"lanczos", 3, 1, 0, 0},
+		{"lanczos", 3, 0.5, 0, 0.607927},
+		{"lanczos", 3, 3.5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		filter, err := NewFilter(tt.name, tt.radius)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		got := filter.Evaluate(tt.x, tt.y)
+		if math.Abs(float64(got-tt.want)) > 1e-5 {
+			t.Errorf("%s(radius %.1f).Evaluate(%.1f, %.1f) = %f, want %f", tt.name, tt.radius, tt.x, tt.y, got, tt.want)
+		}
+		if filter.Radius() != tt.radius {
+			t.Errorf("%s: radius = %f, want %f", tt.name, filter.Radius(), tt.radius)
+		}
+	}
+}
+
+func TestNewFilterRejectsInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name   string
+		radius float32
+	}{
+		{"box", 0},
+		{"gaussian", -1},
+		{"bilinear", 1},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewFilter(tt.name, tt.radius); err == nil {
+			t.Errorf("NewFilter(%q, %.1f) succeeded, want an error", tt.name, tt.radius)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package render
+
+import (
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+var haltonPrimes = [...]uint32{
+	2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53,
+	59, 61, 67, 71, 73, 79, 83, 89, 97, 101, 103, 107, 109, 113, 127, 131,
+}
+
+var _ Sampler = (*HaltonSampler)(nil)
+
+// Halton sequence, decorrelated between pixels by a random toroidal shift (Cranley-Patterson rotation) per dimension.
+// Dimensions beyond the prime table fall back to independent random values.
+type HaltonSampler struct {
+	seed      uint64
+	rng       *common.Rng
+	pixelKey  uint64
+	sampleIdx uint32
+	dimension int
+}
+
+func NewHaltonSampler(seed uint64) *HaltonSampler {
+	return &HaltonSampler{
+		seed: seed,
+		rng:  common.NewRng(),
+	}
+}
+
+func (s *HaltonSampler) StartPixelSample(x, y, sampleIdx int) {
+	s.pixelKey = pixelKey(x, y)
+	s.sampleIdx = uint32(sampleIdx)
+	s.dimension = 0
+	s.rng.Reset(s.seed, s.pixelKey, uint64(sampleIdx))
+}
+
+func (s *HaltonSampler) Get1D() float32 {
+	if s.dimension >= len(haltonPrimes) {
+		return s.rng.Float32()
+	}
+
+	value := s.rotatedRadicalInverse(s.dimension)
+	s.dimension++
+	return value
+}
+
+func (s *HaltonSampler) Get2D() primitive.Vec2 {
+	if s.dimension+1 >= len(haltonPrimes) {
+		return primitive.Vec2{X: s.rng.Float32(), Y: s.rng.Float32()}
+	}
+
+	value := primitive.Vec2{
+		X: s.rotatedRadicalInverse(s.dimension),
+		Y: s.rotatedRadicalInverse(s.dimension + 1),
+	}
+	s.dimension += 2
+	return value
+}
+
+func (s *HaltonSampler) Clone() Sampler {
+	return NewHaltonSampler(s.seed)
+}
+
+func (s *HaltonSampler) rotatedRadicalInverse(dimension int) float32 {
+	offset := uint32ToFloat(uint32(common.MixBits(s.pixelKey ^ common.MixBits(s.seed+uint64(dimension)))))
+	value := radicalInverse(haltonPrimes[dimension], s.sampleIdx) + offset
+	if value >= 1 {
+		value -= 1
+	}
+	return min(value, common.ONE_MINUS_EPSILON)
+}
+
+// Mirrors the digits of the index in the given base around the decimal point
+func radicalInverse(base, index uint32) float32 {
+	invBase := 1 / float64(base)
+	invBaseN := 1.0
+	var reversedDigits uint64
+
+	for index > 0 {
+		next := index / base
+		digit := index - next*base
+		reversedDigits = reversedDigits*uint64(base) + uint64(digit)
+		invBaseN *= invBase
+		index = next
+	}
+
+	return min(float32(float64(reversedDigits)*invBaseN), common.ONE_MINUS_EPSILON)
+}
@@ -0,0 +1,116 @@
+package render
+
+import (
+	"github.com/ruegerj/raytracing/primitive"
+	"github.com/ruegerj/raytracing/scene"
+)
+
+// Volumes a path is currently inside of, in the order they were entered. Overlapping volumes are resolved by their
+// priority (Schmidt & Budge, "Simple Nested Dielectrics in Ray Traced Images").
+type mediumStack struct {
+	boundaries []scene.VolumeBoundary
+}
+
+func newMediumStack() mediumStack {
+	return mediumStack{boundaries: make([]scene.VolumeBoundary, 0, 4)}
+}
+
+// Returns true if a volume of higher priority claims the region on both sides of the boundary
+func (ms *mediumStack) isFalseInterface(boundary scene.VolumeBoundary) bool {
+	for _, b := range ms.boundaries {
+		if b != boundary && b.Priority() > boundary.Priority() {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the IOR on the outer side of the boundary, which is the one of the volume the path is in when entering or
+// the one of the volume it returns to when exiting
+func (ms *mediumStack) exteriorIOR(boundary scene.VolumeBoundary, entering bool) float32 {
+	exclude := -1
+	if !entering {
+		exclude = ms.lastIndexOf(boundary)
+	}
+
+	if top := ms.topExcluding(exclude); top != nil {
+		return top.IOR()
+	}
+	return 1
+}
+
+// Returns the medium of the volume the path currently travels through, nil for vacuum
+func (ms *mediumStack) medium() *scene.Medium {
+	if top := ms.topExcluding(-1); top != nil {
+		return top.Interior()
+	}
+	return nil
+}
+
+// Returns the medium of the volume a path ends up in after passing through the boundary, without updating the stack
+func (ms *mediumStack) mediumBeyond(boundary scene.VolumeBoundary, entering bool) *scene.Medium {
+	if !entering {
+		if top := ms.topExcluding(ms.lastIndexOf(boundary)); top != nil {
+			return top.Interior()
+		}
+		return nil
+	}
+
+	if top := ms.topExcluding(-1); top != nil && top.Priority() > boundary.Priority() {
+		return top.Interior()
+	}
+	return boundary.Interior()
+}
+
+// Updates the stack for a path passing through the boundary
+func (ms *mediumStack) cross(boundary scene.VolumeBoundary, entering bool) {
+	if entering {
+		ms.boundaries = append(ms.boundaries, boundary)
+		return
+	}
+
+	if idx := ms.lastIndexOf(boundary); idx >= 0 {
+		ms.boundaries = append(ms.boundaries[:idx], ms.boundaries[idx+1:]...)
+	}
+}
+
+// Returns the volume of the highest priority, the most recently entered one on equal priorities
+func (ms *mediumStack) topExcluding(exclude int) scene.VolumeBoundary {
+	var top scene.VolumeBoundary
+	for i, b := range ms.boundaries {
+		if i != exclude && (top == nil || b.Priority() >= top.Priority()) {
+			top = b
+		}
+	}
+	return top
+}
+
+func (ms *mediumStack) lastIndexOf(boundary scene.VolumeBoundary) int {
+	for i := len(ms.boundaries) - 1; i >= 0; i-- {
+		if ms.boundaries[i] == boundary {
+			return i
+		}
+	}
+	return -1
+}
+
+// Media on both sides of a surface, attenuating the light sampled directly at a hit. Nil stands for vacuum.
+type surfaceMedia struct {
+	// Medium on the side the path arrived from
+	front *scene.Medium
+	// Medium beyond the surface, reached by transmitted directions
+	back *scene.Medium
+}
+
+// Returns the fraction of light arriving at the hit from the given direction & distance, which may be infinite
+func (sm surfaceMedia) transmittance(hit *scene.Hit, wi primitive.Vec3, distance float32) primitive.ScalarColor {
+	medium := sm.front
+	if wi.Dot(hit.GeometricNormal) < 0 {
+		medium = sm.back
+	}
+
+	if medium == nil {
+		return primitive.ScalarColor{R: 1, G: 1, B: 1}
+	}
+	return medium.Transmittance(distance)
+}
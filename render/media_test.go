@@ -0,0 +1,149 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/ruegerj/raytracing/primitive"
+	"github.com/ruegerj/raytracing/scene"
+)
+
+func createVolume(ior float32, priority int) *scene.PBR {
+	volume := scene.NewPBR(primitive.ScalarColor{R: 1, G: 1, B: 1}, 0, 0)
+	volume.SetTransmission(1, nil)
+	volume.SetIOR(ior)
+	volume.SetPriority(priority)
+	volume.SetInterior(scene.NewMedium(primitive.ScalarColor{R: 0.5, G: 0.5, B: 0.5}, 1))
+	return volume
+}
+
+func TestMediumBeyond(t *testing.T) {
+	water := createVolume(1.33, 0)
+	glass := createVolume(1.5, 1)
+
+	tests := []struct {
+		name     string
+		inside   []scene.VolumeBoundary
+		boundary scene.VolumeBoundary
+		entering bool
+		want     *scene.Medium
+	}{
+		{"entering from vacuum", nil, water, true, water.Interior()},
+		{"exiting into vacuum", []scene.VolumeBoundary{water}, water, false, nil},
+		{"entering a volume of higher priority", []scene.VolumeBoundary{water}, glass, true, glass.Interior()},
+		{"exiting into the surrounding volume", []scene.VolumeBoundary{water, glass}, glass, false, water.Interior()},
+	}
+
+	for _, tt := range tests {
+		media := newMediumStack()
+		for _, b := range tt.inside {
+			media.cross(b, true)
+		}
+
+		if got := media.mediumBeyond(tt.boundary, tt.entering); got != tt.want {
+			t.Errorf("%s: medium beyond = %p, want %p", tt.name, got, tt.want)
+		}
+		// the stack itself stays untouched
+		if len(media.boundaries) != len(tt.inside) {
+			t.Errorf("%s: stack holds %d volumes, want %d", tt.name, len(media.boundaries), len(tt.inside))
+		}
+	}
+}
+
+func TestSurfaceMediaTransmittance(t *testing.T) {
+	front := scene.NewMedium(primitive.ScalarColor{R: 0.5, G: 0.5, B: 0.5}, 1)
+	media := surfaceMedia{front: front}
+	hit := &scene.Hit{GeometricNormal: primitive.Vec3{Z: 1}}
+
+	reflected := media.transmittance(hit, primitive.Vec3{Z: 1}, 2)
+	if reflected != front.Transmittance(2) {
+		t.Errorf("transmittance on the front side = %+v, want %+v", reflected, front.Transmittance(2))
+	}
+	transmitted := media.transmittance(hit, primitive.Vec3{Z: -1}, 2)
+	if transmitted != (primitive.ScalarColor{R: 1, G: 1, B: 1}) {
+		t.Errorf("transmittance through vacuum = %+v, want white", transmitted)
+	}
+}
+
+func TestMediumStackCross(t *testing.T) {
+	water := createVolume(1.33, 0)
+	glass := createVolume(1.5, 0)
+	media := newMediumStack()
+
+	steps := []struct {
+		name       string
+		boundary   scene.VolumeBoundary
+		entering   bool
+		wantMedium *scene.Medium
+	}{
+		{"enter water", water, true, water.Interior()},
+		{"enter glass", glass, true, glass.Interior()},
+		// volumes may be left in any order, the path returns to the most recently entered remaining one
+		{"exit water", water, false, glass.Interior()},
+		{"exit glass", glass, false, nil},
+		// exiting a volume which was never entered leaves the stack untouched
+		{"exit glass again", glass, false, nil},
+	}
+
+	for _, step := range steps {
+		media.cross(step.boundary, step.entering)
+		if got := media.medium(); got != step.wantMedium {
+			t.Errorf("%s: medium = %p, want %p", step.name, got, step.wantMedium)
+		}
+	}
+	if len(media.boundaries) != 0 {
+		t.Errorf("stack holds %d volumes after leaving all, want 0", len(media.boundaries))
+	}
+}
+
+func TestMediumStackResolvesPriorities(t *testing.T) {
+	// liquid in a glass: the glass takes precedence where both overlap
+	liquid := createVolume(1.33, 0)
+	glass := createVolume(1.5, 1)
+	media := newMediumStack()
+
+	media.cross(glass, true)
+	if !media.isFalseInterface(liquid) {
+		t.Error("liquid surface inside the glass is a real interface, want a false one")
+	}
+	media.cross(liquid, true)
+	if got := media.medium(); got != glass.Interior() {
+		t.Errorf("medium where glass & liquid overlap = %p, want the glass %p", got, glass.Interior())
+	}
+
+	media.cross(glass, false)
+	if media.isFalseInterface(liquid) {
+		t.Error("liquid surface outside of the glass is a false interface, want a real one")
+	}
+	if got := media.medium(); got != liquid.Interior() {
+		t.Errorf("medium after leaving the glass = %p, want the liquid %p", got, liquid.Interior())
+	}
+}
+
+func TestMediumStackExteriorIOR(t *testing.T) {
+	water := createVolume(1.33, 0)
+	glass := createVolume(1.5, 0)
+
+	tests := []struct {
+		name     string
+		inside   []scene.VolumeBoundary
+		boundary scene.VolumeBoundary
+		entering bool
+		want     float32
+	}{
+		{"entering from vacuum", nil, glass, true, 1},
+		{"entering from water", []scene.VolumeBoundary{water}, glass, true, 1.33},
+		{"exiting into water", []scene.VolumeBoundary{water, glass}, glass, false, 1.33},
+		{"exiting into vacuum", []scene.VolumeBoundary{glass}, glass, false, 1},
+	}
+
+	for _, tt := range tests {
+		media := newMediumStack()
+		for _, b := range tt.inside {
+			media.cross(b, true)
+		}
+
+		if got := media.exteriorIOR(tt.boundary, tt.entering); got != tt.want {
+			t.Errorf("%s: exterior IOR = %f, want %f", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"image"
 	"log"
+	"math"
+	"runtime"
 	"sync"
 
 	"github.com/ruegerj/raytracing/common"
@@ -14,81 +16,317 @@ import (
 )
 
 var DEFAULT_COLOR = primitive.ScalarColor{R: 0, G: 1, B: 1}
-var renderBar *progressbar.ProgressBar
 
-func Do(world *scene.World, img *image.RGBA) {
+type Options struct {
+	Filter    Filter
+	TileSize  int
+	TileOrder TileOrder
+	// Same sampler seed yields an identical image, regardless of the worker count
+	Sampler Sampler
+	// Every pixel takes at least min samples, then stops as soon as its relative error falls below the
+	// threshold or max samples are reached. A threshold of zero disables adaptive sampling.
+	MinSamples     int
+	MaxSamples     int
+	ErrorThreshold float32
+	// Optional image receiving the per pixel sample count
+	Heatmap *image.RGBA
+	// Replaces indirect lighting by a constant ambient term, only specular bounces are still followed
+	Preview bool
+	// Paths end after max depth bounces, from the russian roulette depth on they may be terminated early
+	MaxDepth             int
+	RussianRouletteDepth int
+}
+
+func Do(world *scene.World, img *image.RGBA, options Options) {
 	width := img.Bounds().Dx()
 	height := img.Bounds().Dy()
-	imageBuffer := make([][]primitive.ScalarColor, height)
+	workerCount := runtime.GOMAXPROCS(0)
+
 	log.Println(fmt.Sprintf("rendering image: %dx%d", img.Bounds().Dx(), img.Bounds().Dy()))
-	log.Println(fmt.Sprintf("samples per pixel: %d", config.SAMPLES))
+	log.Println(fmt.Sprintf("samples per pixel: %d-%d (error threshold: %.4f)", options.MinSamples, options.MaxSamples, options.ErrorThreshold))
+	log.Println(fmt.Sprintf("filter radius: %.2f", options.Filter.Radius()))
+	log.Println(fmt.Sprintf("path depth: %d (russian roulette from %d)", options.MaxDepth, options.RussianRouletteDepth))
+	if options.Preview {
+		log.Println("preview mode: direct & ambient lighting only")
+	}
+
+	film := renderFilm(world, width, height, options, workerCount)
+	film.WriteTo(img)
+
+	if options.Heatmap != nil {
+		writeHeatmap(film.sampleCounts, options.MinSamples, options.MaxSamples, options.Heatmap)
+	}
+}
+
+// Renders all tiles using the given number of workers, the resulting film doesn't depend on the worker count
+func renderFilm(world *scene.World, width, height int, options Options, workerCount int) *Film {
+	film := NewFilm(width, height, options.Filter)
+	tiles := createTiles(width, height, options.TileSize, options.TileOrder)
+	log.Println(fmt.Sprintf("tiles: %d (%s order), workers: %d", len(tiles), options.TileOrder, workerCount))
 
-	renderBar = progressbar.Default(int64(height * width))
+	renderBar := progressbar.Default(int64(len(tiles)), "rendering tiles")
+
+	queue := make(chan int, len(tiles))
+	for tileIdx := range tiles {
+		queue <- tileIdx
+	}
+	close(queue)
 
 	var wg sync.WaitGroup
-	wg.Add(height)
+	wg.Add(workerCount)
 
-	for y := range height {
+	for range workerCount {
 		go func() {
 			defer wg.Done()
-			imageBuffer[y] = renderLine(y, width, world)
+			w := newWorker(world, film, options)
+			for tileIdx := range queue {
+				film.Merge(tileIdx, w.renderTile(tiles[tileIdx]))
+				renderBar.Add(1)
+			}
 		}()
 	}
 
 	wg.Wait()
-	exportBufferToImage(imageBuffer, img)
+	return film
+}
+
+func trace(ray primitive.Ray, world *scene.World, sampler Sampler, options Options) primitive.ScalarColor {
+	radiance := primitive.BLACK
+	throughput := primitive.ScalarColor{R: 1, G: 1, B: 1}
+	// density & lobe of the previous scatter event, used to weight emitters reached by scattering
+	var scatterPdf float32
+	scatterFlags := scene.LOBE_SPECULAR
+	media := newMediumStack()
+
+	for depth := range options.MaxDepth {
+		hit := world.Hits(ray)
+		// paths leaving the scene travel infinitely far through the medium they are in
+		distance := float32(math.Inf(1))
+		if hit != nil {
+			distance = hit.Distance
+		}
+		if medium := media.medium(); medium != nil {
+			throughput = throughput.Mul(medium.Transmittance(distance))
+		}
+
+		if hit == nil {
+			radiance = radiance.Add(throughput.Mul(environmentRadiance(ray, world, scatterPdf, scatterFlags)))
+			break
+		}
+		if hit.Material == nil {
+			radiance = radiance.Add(throughput.Mul(DEFAULT_COLOR))
+			break
+		}
+
+		wo := ray.Direction().Negate()
+		material := hit.Material
+
+		boundary, isBoundary := material.(scene.VolumeBoundary)
+		isBoundary = isBoundary && material.Flags().IsTransmission()
+		if isBoundary {
+			// surfaces inside a volume of higher priority are passed through without any interaction
+			if media.isFalseInterface(boundary) {
+				media.cross(boundary, hit.FrontFace)
+				ray = hit.SpawnRay(ray.Direction())
+				continue
+			}
+			hit.ExteriorIOR = media.exteriorIOR(boundary, hit.FrontFace)
+		}
+
+		if emitted := material.Emitted(hit); !emitted.IsBlack() {
+			// emitters reached by scattering are weighted against the chance of having sampled them directly
+			weight := float32(1)
+			if !scatterFlags.IsSpecular() {
+				emitterPdf := toSolidAngle(world.EmitterPdf(material), hit.Distance, hit.GeometricNormal.Dot(wo))
+				weight = powerHeuristic(scatterPdf, emitterPdf)
+			}
+
+			radiance = radiance.Add(throughput.Mul(emitted).MulScalar(weight))
+		}
+
+		// previews end after the direct lighting of the first non-specular hit, which includes the emitter reached
+		// by its scatter ray
+		if options.Preview && !scatterFlags.IsSpecular() {
+			break
+		}
+
+		bsdfSample, hasSample := material.Sample(wo, hit, sampler.Get1D(), sampler.Get2D())
+
+		if material.Flags().IsNonSpecular() {
+			// light sampled directly is attenuated by the same media as light reached by scattering
+			shadowMedia := surfaceMedia{front: media.medium(), back: media.medium()}
+			if isBoundary {
+				shadowMedia.back = media.mediumBeyond(boundary, hit.FrontFace)
+			}
+
+			radiance = radiance.Add(throughput.Mul(sampleLights(wo, hit, world, shadowMedia)))
+			radiance = radiance.Add(throughput.Mul(sampleEmitters(wo, hit, world, shadowMedia, sampler)))
+			radiance = radiance.Add(throughput.Mul(sampleInfiniteLights(wo, hit, world, shadowMedia, sampler)))
+
+			if ambientShader, ok := material.(scene.AmbientShader); options.Preview && ok {
+				ambient := ambientShader.Ambient(hit).MulScalar(config.AMBIENT_FACTOR)
+				radiance = radiance.Add(throughput.Mul(ambient))
+			}
+		}
+
+		if !hasSample || bsdfSample.Pdf <= 0 || !hit.IsConsistent(bsdfSample.Direction) {
+			break
+		}
+
+		cosTheta := common.Abs(bsdfSample.Direction.Dot(hit.Normal))
+		throughput = throughput.Mul(bsdfSample.F.MulScalar(cosTheta / bsdfSample.Pdf))
+		scatterPdf = bsdfSample.Pdf
+		scatterFlags = bsdfSample.Flags
+		if isBoundary && scatterFlags.IsTransmission() {
+			media.cross(boundary, hit.FrontFace)
+		}
+
+		// russian roulette: terminate paths carrying little energy, survivors are re-weighted to stay unbiased
+		if depth >= options.RussianRouletteDepth {
+			survivalProbability := min(throughput.MaxComponent(), 0.95)
+			if sampler.Get1D() >= survivalProbability {
+				break
+			}
+			throughput = throughput.DivScalar(survivalProbability)
+		}
+
+		ray = hit.SpawnRay(bsdfSample.Direction)
+	}
+
+	return radiance
 }
 
-func renderLine(y int, width int, world *scene.World) []primitive.ScalarColor {
-	line := make([]primitive.ScalarColor, width)
+// Next-event estimation: gathers the direct contribution of every visible light source at the hit
+func sampleLights(wo primitive.Vec3, hit *scene.Hit, world *scene.World, media surfaceMedia) primitive.ScalarColor {
+	directLight := primitive.BLACK
 
-	for x := range width {
-		color := primitive.BLACK
+	for _, light := range world.Lights() {
+		toLight := light.Origin.Sub(hit.Point)
+		distance := toLight.Length()
+		wi := toLight.DivScalar(distance)
+
+		if !hit.IsConsistent(wi) {
+			continue
+		}
+
+		reflectance := hit.Material.Eval(wo, wi, hit)
+		if reflectance.IsBlack() {
+			continue
+		}
 
-		for _ = range config.SAMPLES {
-			ray := world.Camera().RayFrom(x, y)
-			colorPart := trace(ray, config.MAX_DEPTH, world)
-			color = color.Add(colorPart)
+		if world.IsOccluded(hit.RayOrigin(wi), light.Origin) {
+			continue
 		}
 
-		line[x] = color.DivScalar(config.SAMPLES).GammaCorrect()
-		renderBar.Add(1)
+		cosTheta := common.Abs(wi.Dot(hit.Normal))
+		illumination := light.IlluminationAt(distance).Mul(media.transmittance(hit, wi, distance))
+		directLight = directLight.Add(reflectance.Mul(illumination).MulScalar(cosTheta))
 	}
 
-	return line
+	return directLight
 }
 
-func trace(ray primitive.Ray, depth float32, world *scene.World) primitive.ScalarColor {
-	if depth < config.EPSILON {
+// Samples a point on an emissive triangle and returns its contribution, weighted using multiple importance sampling
+func sampleEmitters(wo primitive.Vec3, hit *scene.Hit, world *scene.World, media surfaceMedia, sampler Sampler) primitive.ScalarColor {
+	// the sample dimensions are consumed in any case to keep the sequence aligned between paths
+	uc := sampler.Get1D()
+	u := sampler.Get2D()
+
+	emitterHit, areaPdf := world.SampleEmitter(uc, u)
+	if emitterHit == nil {
 		return primitive.BLACK
 	}
 
-	hit := world.Hits(ray)
-	if hit == nil {
+	toEmitter := emitterHit.Point.Sub(hit.Point)
+	distance := toEmitter.Length()
+	wi := toEmitter.DivScalar(distance)
+
+	// single sided emitters only emit towards the front
+	if scene.CullsBackFaces(emitterHit.Material) && emitterHit.GeometricNormal.Dot(wi) >= 0 {
 		return primitive.BLACK
 	}
-	if hit.Material == nil {
-		return DEFAULT_COLOR
+
+	emitterPdf := toSolidAngle(areaPdf, distance, emitterHit.GeometricNormal.Dot(wi))
+	if emitterPdf <= 0 || !hit.IsConsistent(wi) {
+		return primitive.BLACK
 	}
 
-	reflectedRay, hasRay, color := hit.Material.Scatter(ray, hit, world)
-	if !hasRay {
-		return color
+	reflectance := hit.Material.Eval(wo, wi, hit)
+	if reflectance.IsBlack() {
+		return primitive.BLACK
 	}
 
-	nextColor := trace(reflectedRay, depth-1, world)
-	return color.Mul(correctColorForDepth(nextColor, depth))
+	if world.IsOccluded(hit.RayOrigin(wi), emitterHit.Point) {
+		return primitive.BLACK
+	}
+
+	emitted := emitterHit.Material.Emitted(emitterHit).Mul(media.transmittance(hit, wi, distance))
+	weight := powerHeuristic(emitterPdf, hit.Material.Pdf(wo, wi, hit))
+	cosTheta := common.Abs(wi.Dot(hit.Normal))
+
+	return reflectance.Mul(emitted).MulScalar(cosTheta * weight / emitterPdf)
 }
 
-func correctColorForDepth(color primitive.ScalarColor, depth float32) primitive.ScalarColor {
-	correctionFactor := common.Pow(config.DEPTH_COLOR_DEGRADING_FACTOR, config.MAX_DEPTH-depth)
-	return color.MulScalar(correctionFactor)
+// Returns the light of the infinite lights reached by a ray leaving the scene, each weighted against the chance of
+// having sampled it directly
+func environmentRadiance(ray primitive.Ray, world *scene.World, scatterPdf float32, scatterFlags scene.LobeFlags) primitive.ScalarColor {
+	radiance := primitive.BLACK
+
+	for _, light := range world.InfiniteLights() {
+		incident := light.Radiance(ray.Direction())
+		if incident.IsBlack() {
+			continue
+		}
+
+		if !scatterFlags.IsSpecular() {
+			incident = incident.MulScalar(powerHeuristic(scatterPdf, light.Pdf(ray.Direction())))
+		}
+		radiance = radiance.Add(incident)
+	}
+
+	return radiance
 }
 
-func exportBufferToImage(imageBuffer [][]primitive.ScalarColor, img *image.RGBA) {
-	for y := range imageBuffer {
-		for x := range imageBuffer[y] {
-			img.Set(x, y, imageBuffer[y][x].ToRGBA())
+// Samples a direction towards every infinite light and returns their contribution, weighted using multiple
+// importance sampling
+func sampleInfiniteLights(wo primitive.Vec3, hit *scene.Hit, world *scene.World, media surfaceMedia, sampler Sampler) primitive.ScalarColor {
+	directLight := primitive.BLACK
+
+	for _, light := range world.InfiniteLights() {
+		wi, incident, lightPdf := light.Sample(sampler.Get2D())
+		// rays leaving the scene travel infinitely far, hence only light passing through clear media arrives
+		incident = incident.Mul(media.transmittance(hit, wi, float32(math.Inf(1))))
+		if lightPdf <= 0 || incident.IsBlack() || !hit.IsConsistent(wi) {
+			continue
+		}
+
+		reflectance := hit.Material.Eval(wo, wi, hit)
+		if reflectance.IsBlack() {
+			continue
 		}
+
+		if world.IsOccludedTowards(hit.RayOrigin(wi), wi) {
+			continue
+		}
+
+		weight := powerHeuristic(lightPdf, hit.Material.Pdf(wo, wi, hit))
+		cosTheta := common.Abs(wi.Dot(hit.Normal))
+		directLight = directLight.Add(reflectance.Mul(incident).MulScalar(cosTheta * weight / lightPdf))
 	}
+
+	return directLight
+}
+
+// Converts an area density into a solid angle density as seen from the given distance & angle
+func toSolidAngle(areaPdf, distance, cosTheta float32) float32 {
+	cosTheta = common.Abs(cosTheta)
+	if cosTheta < config.EPSILON {
+		return 0
+	}
+	return areaPdf * distance * distance / cosTheta
+}
+
+func powerHeuristic(pdf, otherPdf float32) float32 {
+	pdfSquared := pdf * pdf
+	return pdfSquared / (pdfSquared + otherPdf*otherPdf)
 }
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/ruegerj/raytracing/config"
+	"github.com/ruegerj/raytracing/primitive"
+	"github.com/ruegerj/raytracing/scene"
+)
+
+func TestRenderIsIndependentOfWorkerCount(t *testing.T) {
+	world := createTestWorld()
+
+	for _, samplerName := range []string{"independent", "stratified", "halton", "sobol"} {
+		t.Run(samplerName, func(t *testing.T) {
+			sampler, err := NewSampler(samplerName, 42, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			options := Options{
+				Filter:         NewGaussianFilter(1.5),
+				TileSize:       8,
+				TileOrder:      TILE_ORDER_HILBERT,
+				Sampler:        sampler,
+				MinSamples:     4,
+				MaxSamples:     16,
+				ErrorThreshold: 0.05,
+				MaxDepth:       8,
+			}
+
+			reference := renderFilm(world, 40, 24, options, 1)
+			for _, workerCount := range []int{2, 8} {
+				film := renderFilm(world, 40, 24, options, workerCount)
+				assertIdenticalFilms(t, reference, film, workerCount)
+			}
+		})
+	}
+}
+
+func TestTraceFollowsPathsUpToTheMaxDepth(t *testing.T) {
+	world := createTestWorld()
+	camera := world.Camera()
+
+	// averages the light gathered by rays through a grid of pixels
+	gather := func(maxDepth, russianRouletteDepth int) float32 {
+		sampler, err := NewSampler("sobol", 42, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options := Options{MaxDepth: maxDepth, RussianRouletteDepth: russianRouletteDepth}
+
+		var sum float32
+		for y := range 8 {
+			for x := range 8 {
+				for sampleIdx := range 64 {
+					sampler.StartPixelSample(x, y, sampleIdx)
+					ray := camera.RayFrom((float32(x)+0.5)*config.WIDTH/8, (float32(y)+0.5)*config.HEIGHT/8)
+					sum += trace(ray, world, sampler, options).Luminance()
+				}
+			}
+		}
+		return sum / (8 * 8 * 64)
+	}
+
+	direct := gather(1, 1)
+	indirect := gather(4, 4)
+	if !(indirect > direct) {
+		t.Errorf("4 bounces gather %f, want more than the %f of a single bounce", indirect, direct)
+	}
+
+	// the roulette re-weights surviving paths, hence it doesn't change the expected radiance
+	roulette := gather(4, 0)
+	if math.Abs(float64(roulette-indirect)) > 0.1*float64(indirect) {
+		t.Errorf("russian roulette from the first bounce gathers %f, want about %f", roulette, indirect)
+	}
+}
+
+func assertIdenticalFilms(t *testing.T, expected, actual *Film, workerCount int) {
+	t.Helper()
+
+	for y := range expected.pixels {
+		for x := range expected.pixels[y] {
+			if expected.pixels[y][x] != actual.pixels[y][x] {
+				t.Fatalf("%d workers: pixel (%d, %d) differs: expected %+v, got %+v",
+					workerCount, x, y, expected.pixels[y][x], actual.pixels[y][x])
+			}
+			if expected.sampleCounts[y][x] != actual.sampleCounts[y][x] {
+				t.Fatalf("%d workers: sample count of pixel (%d, %d) differs: expected %d, got %d",
+					workerCount, x, y, expected.sampleCounts[y][x], actual.sampleCounts[y][x])
+			}
+		}
+	}
+}
+
+// Closed room around the camera, lit by an emissive ceiling panel & a point light, with a glass & a metal pane in it
+func createTestWorld() *scene.World {
+	material := func(color primitive.ScalarColor, metallic, roughness float32) *scene.PBR {
+		pbr := scene.NewPBR(color, metallic, roughness)
+		pbr.SetDoubleSided(true)
+		return pbr
+	}
+
+	white := material(primitive.ScalarColor{R: 0.8, G: 0.8, B: 0.8}, 0, 0.9)
+	red := material(primitive.ScalarColor{R: 0.8, G: 0.1, B: 0.1}, 0, 0.6)
+	metal := material(primitive.ScalarColor{R: 0.9, G: 0.7, B: 0.4}, 1, 0.3)
+	glass := material(primitive.ScalarColor{R: 1, G: 1, B: 1}, 0, 0)
+	glass.SetTransmission(1, nil)
+	light := material(primitive.BLACK, 0, 1)
+	light.SetEmission(primitive.ScalarColor{R: 4, G: 4, B: 4}, nil)
+
+	triangles := make([]scene.Triangle, 0)
+	quad := func(corner, edge1, edge2 primitive.Vec3, material scene.Material) {
+		normal := edge1.Cross(edge2).Normalize()
+		vertex := func(p primitive.Vec3) scene.Vertex {
+			return scene.Vertex{Point: p, Normal: normal}
+		}
+
+		p0, p1, p2, p3 := corner, corner.Add(edge1), corner.Add(edge1).Add(edge2), corner.Add(edge2)
+		triangles = append(triangles,
+			scene.NewTriangle(vertex(p0), vertex(p1), vertex(p2), material),
+			scene.NewTriangle(vertex(p0), vertex(p2), vertex(p3), material),
+		)
+	}
+
+	x := primitive.Vec3{X: 10}
+	y := primitive.Vec3{Y: 10}
+	z := primitive.Vec3{Z: 10}
+	origin := primitive.Vec3{X: -5, Y: -5, Z: -5}
+	quad(origin, x, z, white)
+	quad(origin.Add(y), z, x, white)
+	quad(origin, y, x, red)
+	quad(origin.Add(z), x, y, white)
+	quad(origin, z, y, red)
+	quad(origin.Add(x), y, z, metal)
+	quad(primitive.Vec3{X: -2, Y: 4.9, Z: -2}, primitive.Vec3{Z: 3}, primitive.Vec3{X: 3}, light)
+	quad(primitive.Vec3{X: -4, Y: -3, Z: -3}, primitive.Vec3{X: 3}, primitive.Vec3{Y: 6}, glass)
+	quad(primitive.Vec3{X: -1, Y: -5, Z: -4}, primitive.Vec3{Y: 4}, primitive.Vec3{X: -2, Z: 1}, metal)
+
+	lights := []scene.Light{scene.NewLight(primitive.Vec3{X: 2, Y: 3, Z: 1}, primitive.ScalarColor{R: 1, G: 0.9, B: 0.8}, 2)}
+	camera := scene.NewCamera(16.0/9.0, 0.8, primitive.AffineTransformation{Rotation: mgl32.Ident3()})
+
+	return scene.NewWorld(triangles, lights, camera)
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Source of the sample values of a path, dimensions are consumed in a fixed order:
+// pixel position, then per bounce the BSDF and light choice
+type Sampler interface {
+	// Resets the dimension and positions the sampler on the given sample of a pixel
+	StartPixelSample(x, y, sampleIdx int)
+	Get1D() float32
+	Get2D() primitive.Vec2
+	// Creates an independent instance with the same configuration, e.g. for another worker
+	Clone() Sampler
+}
+
+func NewSampler(name string, seed uint64, samplesPerPixel int) (Sampler, error) {
+	switch name {
+	case "independent":
+		return NewIndependentSampler(seed), nil
+	case "stratified":
+		return NewStratifiedSampler(seed, samplesPerPixel), nil
+	case "halton":
+		return NewHaltonSampler(seed), nil
+	case "sobol":
+		return NewSobolSampler(seed), nil
+	default:
+		return nil, fmt.Errorf("unknown sampler: %s", name)
+	}
+}
+
+var _ Sampler = (*IndependentSampler)(nil)
+
+// Uniform pseudo-random values without any stratification
+type IndependentSampler struct {
+	seed uint64
+	rng  *common.Rng
+}
+
+func NewIndependentSampler(seed uint64) *IndependentSampler {
+	return &IndependentSampler{
+		seed: seed,
+		rng:  common.NewRng(),
+	}
+}
+
+func (s *IndependentSampler) StartPixelSample(x, y, sampleIdx int) {
+	s.rng.Reset(s.seed, pixelKey(x, y), uint64(sampleIdx))
+}
+
+func (s *IndependentSampler) Get1D() float32 {
+	return s.rng.Float32()
+}
+
+func (s *IndependentSampler) Get2D() primitive.Vec2 {
+	return primitive.Vec2{X: s.rng.Float32(), Y: s.rng.Float32()}
+}
+
+func (s *IndependentSampler) Clone() Sampler {
+	return NewIndependentSampler(s.seed)
+}
+
+var _ Sampler = (*StratifiedSampler)(nil)
+
+// Jittered sampling, every dimension of a pixel visits its strata in a differently shuffled order
+type StratifiedSampler struct {
+	seed            uint64
+	samplesPerPixel int
+	strataX         int
+	strataY         int
+	rng             *common.Rng
+	pixelKey        uint64
+	sampleIdx       int
+	dimension       uint64
+}
+
+func NewStratifiedSampler(seed uint64, samplesPerPixel int) *StratifiedSampler {
+	strataX := max(1, int(math.Sqrt(float64(samplesPerPixel))))
+	strataY := (samplesPerPixel + strataX - 1) / strataX
+
+	return &StratifiedSampler{
+		seed:            seed,
+		samplesPerPixel: samplesPerPixel,
+		strataX:         strataX,
+		strataY:         strataY,
+		rng:             common.NewRng(),
+	}
+}
+
+func (s *StratifiedSampler) StartPixelSample(x, y, sampleIdx int) {
+	s.pixelKey = pixelKey(x, y)
+	s.sampleIdx = sampleIdx
+	s.dimension = 0
+	s.rng.Reset(s.seed, s.pixelKey, uint64(sampleIdx))
+}
+
+func (s *StratifiedSampler) Get1D() float32 {
+	strataCount := s.samplesPerPixel
+	stratum := permute(uint32(s.sampleIdx%strataCount), uint32(strataCount), s.dimensionHash())
+	s.dimension++
+
+	return min((float32(stratum)+s.rng.Float32())/float32(strataCount), common.ONE_MINUS_EPSILON)
+}
+
+func (s *StratifiedSampler) Get2D() primitive.Vec2 {
+	strataCount := s.strataX * s.strataY
+	stratum := int(permute(uint32(s.sampleIdx%strataCount), uint32(strataCount), s.dimensionHash()))
+	s.dimension++
+
+	stratumX := stratum % s.strataX
+	stratumY := stratum / s.strataX
+	return primitive.Vec2{
+		X: min((float32(stratumX)+s.rng.Float32())/float32(s.strataX), common.ONE_MINUS_EPSILON),
+		Y: min((float32(stratumY)+s.rng.Float32())/float32(s.strataY), common.ONE_MINUS_EPSILON),
+	}
+}
+
+func (s *StratifiedSampler) Clone() Sampler {
+	return NewStratifiedSampler(s.seed, s.samplesPerPixel)
+}
+
+func (s *StratifiedSampler) dimensionHash() uint32 {
+	return uint32(common.MixBits(s.pixelKey ^ common.MixBits(s.seed+s.dimension)))
+}
+
+func pixelKey(x, y int) uint64 {
+	return uint64(uint32(x)) | uint64(uint32(y))<<32
+}
+
+// Bijective pseudo-random permutation of i in [0, length), see Kensler "Correlated Multi-Jittered Sampling"
+func permute(i, length, seed uint32) uint32 {
+	w := length - 1
+	w |= w >> 1
+	w |= w >> 2
+	w |= w >> 4
+	w |= w >> 8
+	w |= w >> 16
+
+	for {
+		i ^= seed
+		i *= 0xe170893d
+		i ^= seed >> 16
+		i ^= (i & w) >> 4
+		i ^= seed >> 8
+		i *= 0x0929eb3f
+		i ^= seed >> 23
+		i ^= (i & w) >> 1
+		i *= 1 | seed>>27
+		i *= 0x6935fa69
+		i ^= (i & w) >> 11
+		i *= 0x74dcb303
+		i ^= (i & w) >> 2
+		i *= 0x9e501cc3
+		i ^= (i & w) >> 2
+		i *= 0xc860a3df
+		i &= w
+		i ^= i >> 5
+
+		if i < length {
+			break
+		}
+	}
+
+	return (i + seed) % length
+}
+
+func uint32ToFloat(v uint32) float32 {
+	return float32(v>>8) * 0x1p-24
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"math/bits"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+var sobolDirections = createSobolDirections()
+
+var _ Sampler = (*SobolSampler)(nil)
+
+// Owen-scrambled Sobol sequence, where every dimension is padded from the first two Sobol dimensions with its own
+// shuffled index and scramble (Burley, "Practical Hash-based Owen Scrambling")
+type SobolSampler struct {
+	seed      uint64
+	pixelKey  uint64
+	sampleIdx uint32
+	dimension uint64
+}
+
+func NewSobolSampler(seed uint64) *SobolSampler {
+	return &SobolSampler{seed: seed}
+}
+
+func (s *SobolSampler) StartPixelSample(x, y, sampleIdx int) {
+	s.pixelKey = pixelKey(x, y)
+	s.sampleIdx = uint32(sampleIdx)
+	s.dimension = 0
+}
+
+func (s *SobolSampler) Get1D() float32 {
+	seed := s.dimensionSeed()
+	index := nestedUniformScramble(s.sampleIdx, seed)
+
+	return uint32ToFloat(nestedUniformScramble(sobolSample(index, 0), hashCombine(seed, 0)))
+}
+
+func (s *SobolSampler) Get2D() primitive.Vec2 {
+	seed := s.dimensionSeed()
+	index := nestedUniformScramble(s.sampleIdx, seed)
+
+	return primitive.Vec2{
+		X: uint32ToFloat(nestedUniformScramble(sobolSample(index, 0), hashCombine(seed, 0))),
+		Y: uint32ToFloat(nestedUniformScramble(sobolSample(index, 1), hashCombine(seed, 1))),
+	}
+}
+
+func (s *SobolSampler) Clone() Sampler {
+	return NewSobolSampler(s.seed)
+}
+
+func (s *SobolSampler) dimensionSeed() uint32 {
+	seed := uint32(common.MixBits(s.pixelKey ^ common.MixBits(s.seed+s.dimension)))
+	s.dimension++
+	return seed
+}
+
+func sobolSample(index uint32, dimension int) uint32 {
+	var value uint32
+	for bit := 0; index != 0; bit++ {
+		if index&1 != 0 {
+			value ^= sobolDirections[dimension][bit]
+		}
+		index >>= 1
+	}
+	return value
+}
+
+// The first dimension is the van der Corput sequence, the second one uses the primitive polynomial x + 1
+func createSobolDirections() [2][32]uint32 {
+	var directions [2][32]uint32
+	for bit := range 32 {
+		directions[0][bit] = 1 << (31 - bit)
+	}
+
+	directions[1][0] = 1 << 31
+	for bit := 1; bit < 32; bit++ {
+		previous := directions[1][bit-1]
+		directions[1][bit] = previous ^ (previous >> 1)
+	}
+
+	return directions
+}
+
+func nestedUniformScramble(x, seed uint32) uint32 {
+	x = bits.Reverse32(x)
+	x = laineKarrasPermutation(x, seed)
+	return bits.Reverse32(x)
+}
+
+func laineKarrasPermutation(x, seed uint32) uint32 {
+	x += seed
+	x ^= x * 0x6c50b47c
+	x ^= x * 0xb82f1e52
+	x ^= x * 0xc7afe638
+	x ^= x * 0x8d22f6e6
+	return x
+}
+
+func hashCombine(seed, value uint32) uint32 {
+	return seed ^ (value + 0x9e3779b9 + (seed << 6) + (seed >> 2))
+}
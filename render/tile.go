@@ -0,0 +1,123 @@
+package render
+
+import "fmt"
+
+type TileOrder string
+
+const (
+	TILE_ORDER_SPIRAL  TileOrder = "spiral"
+	TILE_ORDER_HILBERT TileOrder = "hilbert"
+)
+
+func ParseTileOrder(name string) (TileOrder, error) {
+	switch order := TileOrder(name); order {
+	case TILE_ORDER_SPIRAL, TILE_ORDER_HILBERT:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unknown tile order: %s", name)
+	}
+}
+
+// Rectangular region of the image, max bounds are exclusive
+type tile struct {
+	minX, minY int
+	maxX, maxY int
+}
+
+func createTiles(width, height, size int, order TileOrder) []tile {
+	tilesX := (width + size - 1) / size
+	tilesY := (height + size - 1) / size
+
+	var cells [][2]int
+	if order == TILE_ORDER_HILBERT {
+		cells = hilbertCells(tilesX, tilesY)
+	} else {
+		cells = spiralCells(tilesX, tilesY)
+	}
+
+	tiles := make([]tile, len(cells))
+	for i, cell := range cells {
+		tiles[i] = tile{
+			minX: cell[0] * size,
+			minY: cell[1] * size,
+			maxX: min(width, (cell[0]+1)*size),
+			maxY: min(height, (cell[1]+1)*size),
+		}
+	}
+
+	return tiles
+}
+
+// Walks a square spiral outwards from the center cell, skipping cells outside the grid
+func spiralCells(tilesX, tilesY int) [][2]int {
+	total := tilesX * tilesY
+	cells := make([][2]int, 0, total)
+
+	x := (tilesX - 1) / 2
+	y := (tilesY - 1) / 2
+	directions := [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	stepLength := 1
+
+	appendIfInside := func(x, y int) {
+		if x >= 0 && x < tilesX && y >= 0 && y < tilesY {
+			cells = append(cells, [2]int{x, y})
+		}
+	}
+
+	appendIfInside(x, y)
+	for dirIdx := 0; len(cells) < total; dirIdx++ {
+		dir := directions[dirIdx%4]
+		for range stepLength {
+			x += dir[0]
+			y += dir[1]
+			appendIfInside(x, y)
+		}
+
+		// the step length grows after every second turn
+		if dirIdx%2 == 1 {
+			stepLength++
+		}
+	}
+
+	return cells
+}
+
+// Orders the cells along a hilbert curve spanning the smallest enclosing power of two grid
+func hilbertCells(tilesX, tilesY int) [][2]int {
+	n := 1
+	for n < max(tilesX, tilesY) {
+		n *= 2
+	}
+
+	cells := make([][2]int, 0, tilesX*tilesY)
+	for d := range n * n {
+		x, y := hilbertToCell(n, d)
+		if x < tilesX && y < tilesY {
+			cells = append(cells, [2]int{x, y})
+		}
+	}
+
+	return cells
+}
+
+func hilbertToCell(n, d int) (int, int) {
+	x, y := 0, 0
+	for s := 1; s < n; s *= 2 {
+		rx := 1 & (d / 2)
+		ry := 1 & (d ^ rx)
+
+		if ry == 0 {
+			if rx == 1 {
+				x = s - 1 - x
+				y = s - 1 - y
+			}
+			x, y = y, x
+		}
+
+		x += s * rx
+		y += s * ry
+		d /= 4
+	}
+
+	return x, y
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"github.com/ruegerj/raytracing/scene"
+)
+
+// Renders tiles one after another, owning its sampler so no state is shared while rendering
+type worker struct {
+	world   *scene.World
+	film    *Film
+	sampler Sampler
+	options Options
+}
+
+func newWorker(world *scene.World, film *Film, options Options) *worker {
+	return &worker{
+		world:   world,
+		film:    film,
+		sampler: options.Sampler.Clone(),
+		options: options,
+	}
+}
+
+func (w *worker) renderTile(t tile) *FilmTile {
+	filmTile := w.film.AcquireTile(t)
+
+	for y := t.minY; y < t.maxY; y++ {
+		for x := t.minX; x < t.maxX; x++ {
+			var statistics pixelStatistics
+
+			for sampleIdx := range w.options.MaxSamples {
+				// every sample is derived from its pixel & index only, so the result is independent of the scheduling
+				w.sampler.StartPixelSample(x, y, sampleIdx)
+
+				// jitter the sample position within the pixel for anti-aliasing
+				pixelOffset := w.sampler.Get2D()
+				filmX := float32(x) + pixelOffset.X
+				filmY := float32(y) + pixelOffset.Y
+
+				ray := w.world.Camera().RayFrom(filmX, filmY)
+				color := trace(ray, w.world, w.sampler, w.options)
+				filmTile.AddSample(filmX, filmY, color)
+
+				statistics.add(color)
+				if w.isAdaptive() && statistics.count >= w.options.MinSamples && statistics.converged(w.options.ErrorThreshold) {
+					break
+				}
+			}
+
+			w.film.sampleCounts[y][x] = statistics.count
+		}
+	}
+
+	return filmTile
+}
+
+func (w *worker) isAdaptive() bool {
+	return w.options.ErrorThreshold > 0
+}
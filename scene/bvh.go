@@ -29,7 +29,24 @@ func NewBvh(triangles []Triangle) *Bvh {
 	return bvh
 }
 
+// Returns the nearest visible hit along the ray, back faces of single sided triangles are ignored
 func (b *Bvh) Intersects(ray primitive.Ray) *Hit {
+	triangle, dist := b.nearest(ray, true)
+	if triangle == nil {
+		return nil
+	}
+
+	return triangle.CreateHitFor(ray, dist)
+}
+
+// Returns the distance to the nearest surface blocking the ray. Single sided triangles block light from both sides,
+// hence no back faces are culled.
+func (b *Bvh) Occludes(ray primitive.Ray) (float32, bool) {
+	triangle, dist := b.nearest(ray, false)
+	return dist, triangle != nil
+}
+
+func (b *Bvh) nearest(ray primitive.Ray, cullBackFaces bool) (*Triangle, float32) {
 	node := &b.nodes[ROOT_INDEX]
 	stack := [64]*BvhNode{node}
 	stackPointer := 0
@@ -41,13 +58,14 @@ func (b *Bvh) Intersects(ray primitive.Ray) *Hit {
 		if node.IsLeaf() {
 			for i := node.firstTri; i < node.firstTri+node.triCount; i++ {
 				tri := &b.triangles[i]
-				hit := tri.Hits(ray)
-				if hit == nil {
+				dist, hit := tri.Hits(ray, cullBackFaces)
+				if !hit {
 					continue
 				}
 
-				if hit.Distance < nearestDist {
-					nearestDist = hit.Distance
+				// partially transparent surfaces may let the ray pass, in which case farther triangles are considered
+				if dist < nearestDist && tri.IsOpaqueAt(ray, dist) {
+					nearestDist = dist
 					nearestTriangle = tri
 				}
 
@@ -96,12 +114,7 @@ func (b *Bvh) Intersects(ray primitive.Ray) *Hit {
 		}
 	}
 
-	if nearestTriangle == nil {
-		return nil
-	}
-
-	hit := nearestTriangle.CreateHitFor(ray, nearestDist)
-	return hit
+	return nearestTriangle, nearestDist
 }
 
 func (b *Bvh) Subdivide(nodeIndex uint) {
@@ -34,9 +34,10 @@ func NewCamera(aspectRatio, yFov float32, transform primitive.AffineTransformati
 	}
 }
 
-func (c Camera) RayFrom(x, y int) primitive.Ray {
-	planeX := (float32(x) - c.halfWidth) * c.meterPerPixel
-	planeY := (c.halfHeight - float32(y)) * c.meterPerPixel
+// Creates the ray through the continuous film position (x, y), where pixel (i, j) spans [i, i+1) x [j, j+1)
+func (c Camera) RayFrom(x, y float32) primitive.Ray {
+	planeX := (x - c.halfWidth) * c.meterPerPixel
+	planeY := (c.halfHeight - y) * c.meterPerPixel
 
 	direction := mgl32.Vec3{planeX, planeY, -c.focalLength}.Normalize()
 	rotatedDirection := c.transform.Rotation.Mul3x1(direction)
@@ -0,0 +1,97 @@
+package scene
+
+import (
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Reflectance at normal incidence of the coating, glTF fixes its IOR at 1.5
+const clearcoat_f0 float32 = 0.04
+
+// Colorless dielectric GGX layer on top of a base material. The coating reflects by the fresnel term of each
+// microfacet as specified by KHR_materials_clearcoat, while the base only receives the energy not reflected by the
+// coating. The latter is approximated by the fresnel term of the coating normal & the viewing direction, as the
+// energy reflected by the microfacets isn't known without integrating over them.
+type clearcoatLayer struct {
+	factor float32
+	// Shading frame around the coating normal, which is facing the viewer
+	frame        primitive.Frame
+	distribution trowbridgeReitz
+}
+
+// Returns the fraction of energy reflected by the coating towards wo, as estimated from the coating normal
+func (c clearcoatLayer) weight(wo primitive.Vec3) float32 {
+	cosTheta := c.frame.Z.Dot(wo)
+	if c.factor <= 0 || cosTheta <= 0 {
+		return 0
+	}
+	return c.fresnel(cosTheta)
+}
+
+// Returns the reflectance of the coating for the given cosine between the viewing direction & the (micro) normal
+func (c clearcoatLayer) fresnel(cosTheta float32) float32 {
+	return c.factor * (clearcoat_f0 + (1-clearcoat_f0)*schlickWeight(cosTheta))
+}
+
+func (c clearcoatLayer) eval(wo, wi primitive.Vec3) primitive.ScalarColor {
+	woLocal := c.frame.ToLocal(wo)
+	wiLocal := c.frame.ToLocal(wi)
+	if woLocal.Z <= 0 || wiLocal.Z <= 0 || c.distribution.effectivelySmooth() {
+		return primitive.BLACK
+	}
+
+	wm := woLocal.Add(wiLocal)
+	if wm.LengthSquared() == 0 {
+		return primitive.BLACK
+	}
+	wm = wm.Normalize()
+
+	specular := c.distribution.D(wm) * c.distribution.G(woLocal, wiLocal) / (4 * woLocal.Z * wiLocal.Z)
+	value := c.fresnel(woLocal.Dot(wm)) * specular
+	return primitive.ScalarColor{R: value, G: value, B: value}
+}
+
+func (c clearcoatLayer) pdf(wo, wi primitive.Vec3) float32 {
+	woLocal := c.frame.ToLocal(wo)
+	wiLocal := c.frame.ToLocal(wi)
+	if woLocal.Z <= 0 || wiLocal.Z <= 0 || c.distribution.effectivelySmooth() {
+		return 0
+	}
+
+	wm := woLocal.Add(wiLocal)
+	if wm.LengthSquared() == 0 {
+		return 0
+	}
+	wm = wm.Normalize()
+
+	return c.distribution.Pdf(woLocal, wm) / (4 * woLocal.Dot(wm))
+}
+
+// Samples a reflected direction, a smooth coating yields a specular sample containing its full reflectance
+func (c clearcoatLayer) sample(wo primitive.Vec3, u primitive.Vec2) (BSDFSample, bool) {
+	woLocal := c.frame.ToLocal(wo)
+	if woLocal.Z <= 0 {
+		return BSDFSample{}, false
+	}
+
+	if c.distribution.effectivelySmooth() {
+		wiLocal := primitive.Vec3{X: -woLocal.X, Y: -woLocal.Y, Z: woLocal.Z}
+		value := c.fresnel(woLocal.Z) / wiLocal.Z
+		return BSDFSample{
+			Direction: c.frame.FromLocal(wiLocal),
+			F:         primitive.ScalarColor{R: value, G: value, B: value},
+			Pdf:       1,
+			Flags:     LOBE_REFLECTION | LOBE_SPECULAR,
+		}, true
+	}
+
+	wm := c.distribution.SampleWm(woLocal, u)
+	wiLocal := woLocal.Negate().Reflect(wm)
+	if wiLocal.Z <= 0 {
+		return BSDFSample{}, false
+	}
+
+	return BSDFSample{
+		Direction: c.frame.FromLocal(wiLocal),
+		Flags:     LOBE_REFLECTION | LOBE_GLOSSY,
+	}, true
+}
@@ -0,0 +1,139 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+var _ InfiniteLight = (*Environment)(nil)
+
+// Infinitely distant light surrounding the scene, given by an equirectangular image with +Y pointing up & the
+// image center facing -Z. The image is rotated around the Y axis & its radiance scaled by the intensity.
+type Environment struct {
+	width, height int
+	texels        []primitive.ScalarColor
+	intensity     float32
+	// rotation around the Y axis in radians
+	sinRotation, cosRotation float32
+	// importance of the texels for next-event estimation
+	distribution *common.Distribution2D
+}
+
+// Expects the texels row by row, starting with the row at the top of the image
+func NewEnvironment(width, height int, texels []primitive.ScalarColor, rotation, intensity float32) *Environment {
+	// texels close to the poles cover a smaller solid angle, hence they are less likely to be hit
+	weights := make([]float32, width*height)
+	for y := range height {
+		sinTheta := float32(math.Sin(math.Pi * (float64(y) + 0.5) / float64(height)))
+		for x := range width {
+			weights[y*width+x] = texels[y*width+x].Luminance() * sinTheta
+		}
+	}
+
+	return &Environment{
+		width:        width,
+		height:       height,
+		texels:       texels,
+		intensity:    intensity,
+		sinRotation:  float32(math.Sin(float64(rotation))),
+		cosRotation:  float32(math.Cos(float64(rotation))),
+		distribution: common.NewDistribution2D(weights, width, height),
+	}
+}
+
+// Bilinearly interpolated between the texels
+func (e *Environment) Radiance(direction primitive.Vec3) primitive.ScalarColor {
+	uv := e.toImage(direction)
+
+	fx := uv.X*float32(e.width) - 0.5
+	fy := uv.Y*float32(e.height) - 0.5
+	x0 := float32(math.Floor(float64(fx)))
+	y0 := float32(math.Floor(float64(fy)))
+	tx := fx - x0
+	ty := fy - y0
+
+	x, y := int(x0), int(y0)
+	top := lerpColor(tx, e.texel(x, y), e.texel(x+1, y))
+	bottom := lerpColor(tx, e.texel(x, y+1), e.texel(x+1, y+1))
+	return lerpColor(ty, top, bottom).MulScalar(e.intensity)
+}
+
+// Picks directions proportional to the radiance of the texels
+func (e *Environment) Sample(u primitive.Vec2) (primitive.Vec3, primitive.ScalarColor, float32) {
+	x, y, uvPdf := e.distribution.Sample(u.X, u.Y)
+	if uvPdf <= 0 {
+		return primitive.Vec3{}, primitive.BLACK, 0
+	}
+
+	direction := e.fromImage(primitive.Vec2{X: x, Y: y})
+	pdf := toDirectionPdf(uvPdf, y)
+	if pdf <= 0 {
+		return primitive.Vec3{}, primitive.BLACK, 0
+	}
+
+	return direction, e.Radiance(direction), pdf
+}
+
+func (e *Environment) Pdf(direction primitive.Vec3) float32 {
+	uv := e.toImage(direction)
+	return toDirectionPdf(e.distribution.Pdf(uv.X, uv.Y), uv.Y)
+}
+
+// Maps a world space direction onto normalized image coordinates
+func (e *Environment) toImage(direction primitive.Vec3) primitive.Vec2 {
+	// undo the rotation of the environment
+	local := primitive.Vec3{
+		X: direction.X*e.cosRotation - direction.Z*e.sinRotation,
+		Y: direction.Y,
+		Z: direction.X*e.sinRotation + direction.Z*e.cosRotation,
+	}.Normalize()
+
+	phi := math.Atan2(float64(local.X), float64(-local.Z))
+	theta := math.Acos(float64(min(max(local.Y, -1), 1)))
+
+	return primitive.Vec2{
+		X: float32(0.5 + phi/(2*math.Pi)),
+		Y: float32(theta / math.Pi),
+	}
+}
+
+// Maps normalized image coordinates onto a world space direction
+func (e *Environment) fromImage(uv primitive.Vec2) primitive.Vec3 {
+	local := equirectangularDirection(uv)
+	return primitive.Vec3{
+		X: local.X*e.cosRotation + local.Z*e.sinRotation,
+		Y: local.Y,
+		Z: -local.X*e.sinRotation + local.Z*e.cosRotation,
+	}
+}
+
+// Wraps around horizontally & clamps at the poles
+func (e *Environment) texel(x, y int) primitive.ScalarColor {
+	x = positiveMod(x, e.width)
+	y = min(max(y, 0), e.height-1)
+	return e.texels[y*e.width+x]
+}
+
+// Converts a density over the image into a solid angle density, the image spans 2π x π with rows shrinking by sinθ
+func toDirectionPdf(uvPdf, v float32) float32 {
+	sinTheta := float32(math.Sin(float64(v) * math.Pi))
+	if sinTheta <= 0 {
+		return 0
+	}
+	return uvPdf / (2 * math.Pi * math.Pi * sinTheta)
+}
+
+// Returns the direction of the normalized coordinates in an unrotated equirectangular image
+func equirectangularDirection(uv primitive.Vec2) primitive.Vec3 {
+	phi := (float64(uv.X) - 0.5) * 2 * math.Pi
+	theta := float64(uv.Y) * math.Pi
+	sinTheta := math.Sin(theta)
+
+	return primitive.Vec3{
+		X: float32(sinTheta * math.Sin(phi)),
+		Y: float32(math.Cos(theta)),
+		Z: float32(-sinTheta * math.Cos(phi)),
+	}
+}
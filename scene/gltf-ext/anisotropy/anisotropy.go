@@ -0,0 +1,21 @@
+package anisotropy
+
+import (
+	"encoding/json"
+
+	"github.com/qmuntal/gltf"
+)
+
+const ExtensionName = "KHR_materials_anisotropy"
+
+func Unmarshal(data []byte) (any, error) {
+	matAnisotropy := new(MaterialsAnisotropy)
+	err := json.Unmarshal(data, matAnisotropy)
+	return matAnisotropy, err
+}
+
+type MaterialsAnisotropy struct {
+	AnisotropyStrength *float32          `json:"anisotropyStrength,omitempty"`
+	AnisotropyRotation *float32          `json:"anisotropyRotation,omitempty"`
+	AnisotropyTexture  *gltf.TextureInfo `json:"anisotropyTexture,omitempty"`
+}
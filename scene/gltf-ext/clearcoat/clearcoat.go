@@ -0,0 +1,23 @@
+package clearcoat
+
+import (
+	"encoding/json"
+
+	"github.com/qmuntal/gltf"
+)
+
+const ExtensionName = "KHR_materials_clearcoat"
+
+func Unmarshal(data []byte) (any, error) {
+	matClearcoat := new(MaterialsClearcoat)
+	err := json.Unmarshal(data, matClearcoat)
+	return matClearcoat, err
+}
+
+type MaterialsClearcoat struct {
+	ClearcoatFactor           *float32            `json:"clearcoatFactor,omitempty"`
+	ClearcoatTexture          *gltf.TextureInfo   `json:"clearcoatTexture,omitempty"`
+	ClearcoatRoughnessFactor  *float32            `json:"clearcoatRoughnessFactor,omitempty"`
+	ClearcoatRoughnessTexture *gltf.TextureInfo   `json:"clearcoatRoughnessTexture,omitempty"`
+	ClearcoatNormalTexture    *gltf.NormalTexture `json:"clearcoatNormalTexture,omitempty"`
+}
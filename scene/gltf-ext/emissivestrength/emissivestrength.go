@@ -0,0 +1,17 @@
+package emissivestrength
+
+import (
+	"encoding/json"
+)
+
+const ExtensionName = "KHR_materials_emissive_strength"
+
+func Unmarshal(data []byte) (any, error) {
+	matEmissiveStrength := new(MaterialsEmissiveStrength)
+	err := json.Unmarshal(data, matEmissiveStrength)
+	return matEmissiveStrength, err
+}
+
+type MaterialsEmissiveStrength struct {
+	EmissiveStrength *float32 `json:"emissiveStrength,omitempty"`
+}
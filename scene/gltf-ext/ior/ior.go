@@ -0,0 +1,17 @@
+package ior
+
+import (
+	"encoding/json"
+)
+
+const ExtensionName = "KHR_materials_ior"
+
+func Unmarshal(data []byte) (any, error) {
+	matIor := new(MaterialsIor)
+	err := json.Unmarshal(data, matIor)
+	return matIor, err
+}
+
+type MaterialsIor struct {
+	Ior *float32 `json:"ior,omitempty"`
+}
@@ -0,0 +1,47 @@
+package iridescence
+
+import (
+	"encoding/json"
+
+	"github.com/qmuntal/gltf"
+)
+
+const ExtensionName = "KHR_materials_iridescence"
+
+func Unmarshal(data []byte) (any, error) {
+	matIridescence := new(MaterialsIridescence)
+	err := json.Unmarshal(data, matIridescence)
+	return matIridescence, err
+}
+
+type MaterialsIridescence struct {
+	IridescenceFactor           *float32          `json:"iridescenceFactor,omitempty"`
+	IridescenceTexture          *gltf.TextureInfo `json:"iridescenceTexture,omitempty"`
+	IridescenceIor              *float32          `json:"iridescenceIor,omitempty"`
+	IridescenceThicknessMinimum *float32          `json:"iridescenceThicknessMinimum,omitempty"`
+	IridescenceThicknessMaximum *float32          `json:"iridescenceThicknessMaximum,omitempty"`
+	IridescenceThicknessTexture *gltf.TextureInfo `json:"iridescenceThicknessTexture,omitempty"`
+}
+
+func (m *MaterialsIridescence) IridescenceIorOrDefault() float32 {
+	if m.IridescenceIor == nil {
+		return 1.3
+	}
+	return *m.IridescenceIor
+}
+
+// Thickness of the film in nanometers
+func (m *MaterialsIridescence) IridescenceThicknessMinimumOrDefault() float32 {
+	if m.IridescenceThicknessMinimum == nil {
+		return 100
+	}
+	return *m.IridescenceThicknessMinimum
+}
+
+// Thickness of the film in nanometers
+func (m *MaterialsIridescence) IridescenceThicknessMaximumOrDefault() float32 {
+	if m.IridescenceThicknessMaximum == nil {
+		return 400
+	}
+	return *m.IridescenceThicknessMaximum
+}
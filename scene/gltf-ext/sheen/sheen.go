@@ -0,0 +1,22 @@
+package sheen
+
+import (
+	"encoding/json"
+
+	"github.com/qmuntal/gltf"
+)
+
+const ExtensionName = "KHR_materials_sheen"
+
+func Unmarshal(data []byte) (any, error) {
+	matSheen := new(MaterialsSheen)
+	err := json.Unmarshal(data, matSheen)
+	return matSheen, err
+}
+
+type MaterialsSheen struct {
+	SheenColorFactor      *[3]float32       `json:"sheenColorFactor,omitempty"`
+	SheenColorTexture     *gltf.TextureInfo `json:"sheenColorTexture,omitempty"`
+	SheenRoughnessFactor  *float32          `json:"sheenRoughnessFactor,omitempty"`
+	SheenRoughnessTexture *gltf.TextureInfo `json:"sheenRoughnessTexture,omitempty"`
+}
@@ -0,0 +1,36 @@
+package specular
+
+import (
+	"encoding/json"
+
+	"github.com/qmuntal/gltf"
+)
+
+const ExtensionName = "KHR_materials_specular"
+
+func Unmarshal(data []byte) (any, error) {
+	matSpecular := new(MaterialsSpecular)
+	err := json.Unmarshal(data, matSpecular)
+	return matSpecular, err
+}
+
+type MaterialsSpecular struct {
+	SpecularFactor       *float32          `json:"specularFactor,omitempty"`
+	SpecularTexture      *gltf.TextureInfo `json:"specularTexture,omitempty"`
+	SpecularColorFactor  *[3]float32       `json:"specularColorFactor,omitempty"`
+	SpecularColorTexture *gltf.TextureInfo `json:"specularColorTexture,omitempty"`
+}
+
+func (m *MaterialsSpecular) SpecularFactorOrDefault() float32 {
+	if m.SpecularFactor == nil {
+		return 1
+	}
+	return *m.SpecularFactor
+}
+
+func (m *MaterialsSpecular) SpecularColorFactorOrDefault() [3]float32 {
+	if m.SpecularColorFactor == nil {
+		return [3]float32{1, 1, 1}
+	}
+	return *m.SpecularColorFactor
+}
@@ -0,0 +1,22 @@
+package volume
+
+import (
+	"encoding/json"
+
+	"github.com/qmuntal/gltf"
+)
+
+const ExtensionName = "KHR_materials_volume"
+
+func Unmarshal(data []byte) (any, error) {
+	matVolume := new(MaterialsVolume)
+	err := json.Unmarshal(data, matVolume)
+	return matVolume, err
+}
+
+type MaterialsVolume struct {
+	ThicknessFactor     *float32          `json:"thicknessFactor,omitempty"`
+	ThicknessTexture    *gltf.TextureInfo `json:"thicknessTexture,omitempty"`
+	AttenuationDistance *float32          `json:"attenuationDistance,omitempty"`
+	AttenuationColor    *[3]float32       `json:"attenuationColor,omitempty"`
+}
@@ -1,14 +1,91 @@
 package scene
 
 import (
+	"github.com/ruegerj/raytracing/config"
 	"github.com/ruegerj/raytracing/primitive"
 )
 
+// Below this cosine between wo & the shading normal, the shading normal is bent towards wo
+const min_shading_cos float32 = 0.01
+
 type Hit struct {
-	Distance  float32
-	Point     primitive.Vec3
-	Normal    primitive.Vec3
-	UV        *primitive.Vec2
-	FrontFace bool
-	Material  Material
+	Distance float32
+	Point    primitive.Vec3
+	// True normal of the triangle, facing the side the ray arrived from
+	GeometricNormal primitive.Vec3
+	// Shading normal, which is interpolated, possibly perturbed & kept in the hemisphere of wo
+	Normal primitive.Vec3
+	// Interpolated normal before normal mapping, the tangents complete the frame around it (zero without tangents)
+	VertexNormal primitive.Vec3
+	Tangent      primitive.Vec3
+	Bitangent    primitive.Vec3
+	UV           *primitive.Vec2
+	FrontFace    bool
+	Material     Material
+	// IOR of the medium surrounding the surface, as determined by the integrator. Zero is treated as vacuum.
+	ExteriorIOR float32
+	// Point lifted onto the smooth surface implied by the vertex normals, equal to the point on flat surfaces
+	offsetPoint primitive.Vec3
+}
+
+// Creates a ray leaving the hit point, slightly offset to prevent self intersections
+func (h *Hit) SpawnRay(direction primitive.Vec3) primitive.Ray {
+	return primitive.NewRay(h.RayOrigin(direction).Add(direction.MulScalar(config.EPSILON)), direction)
+}
+
+// Returns the point rays in the given direction should start from. Rays leaving on the side of wo start from the
+// offset point to avoid shadow terminator artifacts, transmitted rays from the actual point.
+func (h *Hit) RayOrigin(direction primitive.Vec3) primitive.Vec3 {
+	if direction.Dot(h.GeometricNormal) > 0 {
+		return h.offsetPoint
+	}
+	return h.Point
+}
+
+// Checks whether wi lies on the same side of the geometric & the shading normal. Directions in between would let
+// light leak through the surface, as the material treats reflection & transmission by the shading normal.
+func (h *Hit) IsConsistent(wi primitive.Vec3) bool {
+	return wi.Dot(h.GeometricNormal)*wi.Dot(h.Normal) > 0
+}
+
+// Bends the shading normal towards wo until wo lies slightly above its hemisphere, as shading normals facing away
+// from the viewer produce black spots & let materials reflect into the surface
+func (h *Hit) correctShadingNormal(wo primitive.Vec3) {
+	cosTheta := h.Normal.Dot(wo)
+	if cosTheta >= min_shading_cos {
+		return
+	}
+	h.Normal = h.Normal.Add(wo.MulScalar(min_shading_cos - cosTheta)).Normalize()
+}
+
+// Orthonormalizes the given tangent against the vertex normal (Gram-Schmidt) & derives the bitangent from it
+func (h *Hit) setTangentFrame(tangent primitive.Vec3, handedness float32) {
+	tangent = tangent.Sub(h.VertexNormal.MulScalar(h.VertexNormal.Dot(tangent)))
+	if tangent.LengthSquared() == 0 {
+		return
+	}
+
+	h.Tangent = tangent.Normalize()
+	h.Bitangent = h.VertexNormal.Cross(h.Tangent).MulScalar(handedness)
+}
+
+// Returns the direction encoded in a tangent space normal map at the hit, the scale is applied to the X & Y
+// components. Falls back to the vertex normal if the surface has no texture coordinates or tangents.
+func (h *Hit) perturbedNormal(normalMap *Texture, scale float32) primitive.Vec3 {
+	if normalMap == nil || h.UV == nil || h.Tangent.LengthSquared() == 0 {
+		return h.VertexNormal
+	}
+
+	texel := normalMap.Sample(*h.UV)
+	local := primitive.Vec3{
+		X: (2*texel.R - 1) * scale,
+		Y: (2*texel.G - 1) * scale,
+		Z: 2*texel.B - 1,
+	}
+
+	perturbed := primitive.Frame{X: h.Tangent, Y: h.Bitangent, Z: h.VertexNormal}.FromLocal(local)
+	if perturbed.LengthSquared() == 0 {
+		return h.VertexNormal
+	}
+	return perturbed.Normalize()
 }
@@ -0,0 +1,222 @@
+package imprt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ruegerj/raytracing/primitive"
+	"github.com/ruegerj/raytracing/scene"
+)
+
+// Loads an equirectangular environment map from a Radiance (.hdr) or portable float map (.pfm) file. The rotation
+// around the Y axis is given in radians.
+func LoadEnvironment(path string, rotation, intensity float32) (*scene.Environment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var width, height int
+	var texels []primitive.ScalarColor
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdr", ".pic":
+		width, height, texels, err = readRadiance(reader)
+	case ".pfm":
+		width, height, texels, err = readPFM(reader)
+	default:
+		return nil, fmt.Errorf("environment %s: unsupported format, expected .hdr or .pfm", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("environment %s: %w", path, err)
+	}
+
+	log.Printf("environment map: %dx%d\n", width, height)
+	return scene.NewEnvironment(width, height, texels, rotation, intensity), nil
+}
+
+// Decodes RGBE encoded pixels, either stored flat or as run-length encoded scanlines
+func readRadiance(reader *bufio.Reader) (int, int, []primitive.ScalarColor, error) {
+	magic, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if !strings.HasPrefix(magic, "#?") {
+		return 0, 0, nil, errors.New("missing radiance header")
+	}
+
+	// header lines end with an empty line, followed by the resolution
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if format, ok := strings.CutPrefix(line, "FORMAT="); ok && format != "32-bit_rle_rgbe" {
+			return 0, 0, nil, fmt.Errorf("unsupported pixel format %s", format)
+		}
+	}
+
+	var width, height int
+	resolution, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if _, err := fmt.Sscanf(resolution, "-Y %d +X %d", &height, &width); err != nil {
+		return 0, 0, nil, fmt.Errorf("unsupported resolution %q", strings.TrimSpace(resolution))
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, nil, errors.New("empty image")
+	}
+
+	texels := make([]primitive.ScalarColor, width*height)
+	scanline := make([]byte, 4*width)
+	for y := range height {
+		if err := readRadianceScanline(reader, scanline, width); err != nil {
+			return 0, 0, nil, err
+		}
+		for x := range width {
+			texels[y*width+x] = rgbeToColor(scanline[4*x : 4*x+4])
+		}
+	}
+
+	return width, height, texels, nil
+}
+
+// Reads the RGBE pixels of a scanline interleaved into the given buffer
+func readRadianceScanline(reader *bufio.Reader, scanline []byte, width int) error {
+	// run-length encoded scanlines start with two 2s followed by their width
+	start, err := reader.Peek(4)
+	if err != nil {
+		return err
+	}
+	isEncoded := width >= 8 && width < 0x8000 && start[0] == 2 && start[1] == 2 && start[2]&0x80 == 0
+	if !isEncoded {
+		_, err := io.ReadFull(reader, scanline)
+		return err
+	}
+	if int(start[2])<<8|int(start[3]) != width {
+		return errors.New("scanline width mismatch")
+	}
+	if _, err := reader.Discard(4); err != nil {
+		return err
+	}
+
+	// each channel is encoded separately as a sequence of runs & literal bytes
+	for channel := range 4 {
+		for x := 0; x < width; {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			if count > 128 {
+				run := int(count - 128)
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+run > width {
+					return errors.New("run exceeds scanline")
+				}
+				for range run {
+					scanline[4*x+channel] = value
+					x++
+				}
+				continue
+			}
+
+			if count == 0 || x+int(count) > width {
+				return errors.New("invalid literal run")
+			}
+			for range count {
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+				scanline[4*x+channel] = value
+				x++
+			}
+		}
+	}
+
+	return nil
+}
+
+// The shared exponent scales all three mantissas
+func rgbeToColor(rgbe []byte) primitive.ScalarColor {
+	if rgbe[3] == 0 {
+		return primitive.BLACK
+	}
+	scale := float32(math.Ldexp(1, int(rgbe[3])-(128+8)))
+	return primitive.ScalarColor{
+		R: float32(rgbe[0]) * scale,
+		G: float32(rgbe[1]) * scale,
+		B: float32(rgbe[2]) * scale,
+	}
+}
+
+// Decodes a color (PF) or grayscale (Pf) float map, whose rows are stored from the bottom to the top
+func readPFM(reader *bufio.Reader) (int, int, []primitive.ScalarColor, error) {
+	var magic string
+	var width, height int
+	var scale float32
+	if _, err := fmt.Fscan(reader, &magic, &width, &height, &scale); err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid pfm header: %w", err)
+	}
+	// a single whitespace character separates the header from the data
+	if _, err := reader.ReadByte(); err != nil {
+		return 0, 0, nil, err
+	}
+
+	var channels int
+	switch magic {
+	case "PF":
+		channels = 3
+	case "Pf":
+		channels = 1
+	default:
+		return 0, 0, nil, fmt.Errorf("unsupported pfm type %s", magic)
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, nil, errors.New("empty image")
+	}
+
+	// the sign of the scale denotes the byte order
+	var byteOrder binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		byteOrder = binary.LittleEndian
+	}
+
+	values := make([]float32, width*height*channels)
+	if err := binary.Read(reader, byteOrder, values); err != nil {
+		return 0, 0, nil, err
+	}
+
+	texels := make([]primitive.ScalarColor, width*height)
+	for y := range height {
+		row := (height - 1 - y) * width * channels
+		for x := range width {
+			value := values[row+x*channels:]
+			if channels == 1 {
+				texels[y*width+x] = primitive.ScalarColor{R: value[0], G: value[0], B: value[0]}
+				continue
+			}
+			texels[y*width+x] = primitive.ScalarColor{R: value[0], G: value[1], B: value[2]}
+		}
+	}
+
+	return width, height, texels, nil
+}
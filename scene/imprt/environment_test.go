@@ -0,0 +1,167 @@
+package imprt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+func readerOf(data []byte) *bufio.Reader {
+	return bufio.NewReader(bytes.NewReader(data))
+}
+
+func assertTexels(t *testing.T, got, want []primitive.ScalarColor) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d texels, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("texel %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadRadianceFlat(t *testing.T) {
+	data := []byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 2 +X 2\n")
+	data = append(data,
+		128, 64, 0, 129, // exponent 1: (1, 0.5, 0)
+		0, 0, 0, 0,
+		255, 255, 255, 0, // a zero exponent is black regardless of the mantissas
+		128, 128, 128, 136,
+	)
+
+	width, height, texels, err := readRadiance(readerOf(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 2 || height != 2 {
+		t.Fatalf("resolution = %dx%d, want 2x2", width, height)
+	}
+	assertTexels(t, texels, []primitive.ScalarColor{
+		{R: 1, G: 0.5, B: 0},
+		primitive.BLACK,
+		primitive.BLACK,
+		{R: 128, G: 128, B: 128},
+	})
+}
+
+func TestReadRadianceRunLengthEncoded(t *testing.T) {
+	data := []byte("#?RGBE\n# comment\n\n-Y 1 +X 8\n")
+	data = append(data, 2, 2, 0, 8)
+	// red: single run, green: literal bytes, blue: two runs, exponent: single run
+	data = append(data, 128+8, 128)
+	data = append(data, 8, 0, 16, 32, 48, 64, 80, 96, 112)
+	data = append(data, 128+4, 0, 128+4, 64)
+	data = append(data, 128+8, 129)
+
+	width, height, texels, err := readRadiance(readerOf(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if width != 8 || height != 1 {
+		t.Fatalf("resolution = %dx%d, want 8x1", width, height)
+	}
+
+	want := make([]primitive.ScalarColor, 8)
+	for x := range want {
+		want[x] = primitive.ScalarColor{R: 1, G: float32(16*x) / 128}
+		if x >= 4 {
+			want[x].B = 0.5
+		}
+	}
+	assertTexels(t, texels, want)
+}
+
+func TestReadRadianceErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"missing magic", "RADIANCE\n\n-Y 1 +X 1\n\x00\x00\x00\x00"},
+		{"unsupported format", "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n\x00\x00\x00\x00"},
+		{"unsupported orientation", "#?RADIANCE\n\n+Y 1 +X 1\n\x00\x00\x00\x00"},
+		{"truncated pixels", "#?RADIANCE\n\n-Y 1 +X 2\n\x00\x00\x00\x00"},
+		{"run exceeding scanline", "#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x08\x8a\x00"},
+	}
+
+	for _, tt := range tests {
+		if _, _, _, err := readRadiance(readerOf([]byte(tt.data))); err == nil {
+			t.Errorf("%s: succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func TestReadPFM(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		order  binary.ByteOrder
+		values []float32
+		want   []primitive.ScalarColor
+	}{
+		{
+			// rows are stored from the bottom to the top
+			name:   "color little endian",
+			header: "PF\n1 2\n-1.0\n",
+			order:  binary.LittleEndian,
+			values: []float32{1, 2, 3, 4, 5, 6},
+			want:   []primitive.ScalarColor{{R: 4, G: 5, B: 6}, {R: 1, G: 2, B: 3}},
+		},
+		{
+			name:   "grayscale big endian",
+			header: "Pf 2 1 1.0\n",
+			order:  binary.BigEndian,
+			values: []float32{0.25, 8},
+			want:   []primitive.ScalarColor{{R: 0.25, G: 0.25, B: 0.25}, {R: 8, G: 8, B: 8}},
+		},
+	}
+
+	for _, tt := range tests {
+		var data bytes.Buffer
+		data.WriteString(tt.header)
+		if err := binary.Write(&data, tt.order, tt.values); err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, texels, err := readPFM(readerOf(data.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		assertTexels(t, texels, tt.want)
+	}
+}
+
+func TestReadPFMErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unsupported type", "P6\n1 1\n255\n\x00\x00\x00"},
+		{"invalid header", "PF\nwide 1\n-1.0\n"},
+		{"truncated data", "PF\n1 1\n-1.0\n\x00\x00\x00\x00"},
+	}
+
+	for _, tt := range tests {
+		if _, _, _, err := readPFM(readerOf([]byte(tt.data))); err == nil {
+			t.Errorf("%s: succeeded, want an error", tt.name)
+		}
+	}
+}
+
+func TestLoadEnvironmentRejectsUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "environment.exr")
+	if err := os.WriteFile(path, []byte{0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadEnvironment(path, 0, 1); err == nil {
+		t.Errorf("LoadEnvironment(%s) succeeded, want an error", path)
+	}
+}
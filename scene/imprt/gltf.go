@@ -1,24 +1,36 @@
 package imprt
 
 import (
-	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"path/filepath"
+
 	"github.com/qmuntal/gltf"
 	"github.com/qmuntal/gltf/ext/lightspunctual"
 	"github.com/qmuntal/gltf/modeler"
 	"github.com/ruegerj/raytracing/config"
 	"github.com/ruegerj/raytracing/primitive"
 	"github.com/ruegerj/raytracing/scene"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/anisotropy"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/clearcoat"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/emissivestrength"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/ior"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/iridescence"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/sheen"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/specular"
 	"github.com/ruegerj/raytracing/scene/gltf-ext/transmission"
+	"github.com/ruegerj/raytracing/scene/gltf-ext/volume"
 )
 
-var defaultLight = scene.Light{
-	Origin:    primitive.Vec3{X: -2.5, Y: 3, Z: 2},
-	Color:     primitive.ScalarColor{R: 1, G: 1, B: 1},
-	Intensity: 1,
-}
-
 func InitGltfEtensions() {
 	gltf.RegisterExtension(transmission.ExtensionName, transmission.Unmarshal)
+	gltf.RegisterExtension(emissivestrength.ExtensionName, emissivestrength.Unmarshal)
+	gltf.RegisterExtension(ior.ExtensionName, ior.Unmarshal)
+	gltf.RegisterExtension(volume.ExtensionName, volume.Unmarshal)
+	gltf.RegisterExtension(clearcoat.ExtensionName, clearcoat.Unmarshal)
+	gltf.RegisterExtension(sheen.ExtensionName, sheen.Unmarshal)
+	gltf.RegisterExtension(specular.ExtensionName, specular.Unmarshal)
+	gltf.RegisterExtension(anisotropy.ExtensionName, anisotropy.Unmarshal)
+	gltf.RegisterExtension(iridescence.ExtensionName, iridescence.Unmarshal)
 }
 
 func FromGLTF(path string) (*scene.World, error) {
@@ -27,36 +39,46 @@ func FromGLTF(path string) (*scene.World, error) {
 		return nil, err
 	}
 
-	materials := loadMaterials(doc)
+	textures := newTextureLoader(doc, filepath.Dir(path))
+	materials, err := loadMaterials(doc, textures)
+	if err != nil {
+		return nil, err
+	}
+	nodes := collectSceneNodes(doc)
 
-	triangles, err := loadTriangles(doc, materials)
+	triangles, err := loadTriangles(doc, nodes, materials)
 	if err != nil {
 		return nil, err
 	}
 
-	cameras, err := loadCameras(doc)
+	cameras, err := loadCameras(doc, nodes)
 	if err != nil {
 		return nil, err
 	}
 
-	lightSources, err := loadLightSources(doc)
+	lightSources, sunDirection, err := loadLightSources(doc, nodes)
 	if err != nil {
 		return nil, err
 	}
 
 	world := scene.NewWorld(triangles, lightSources, cameras[0])
+	if sunDirection != nil {
+		world.SetSunDirection(*sunDirection)
+	}
 
 	return world, nil
 }
 
-func loadTriangles(doc *gltf.Document, materials []scene.Material) ([]scene.Triangle, error) {
+func loadTriangles(doc *gltf.Document, nodes []sceneNode, materials []scene.Material) ([]scene.Triangle, error) {
 	triangles := make([]scene.Triangle, 0)
-	for _, node := range doc.Nodes {
+	for _, sn := range nodes {
+		node := sn.node
 		if node.Mesh == nil {
 			continue
 		}
 
 		mesh := doc.Meshes[*node.Mesh]
+		normalMat := normalMatrix(sn.transform)
 
 		for _, prim := range mesh.Primitives {
 
@@ -90,18 +112,42 @@ func loadTriangles(doc *gltf.Document, materials []scene.Material) ([]scene.Tria
 				}
 			}
 
+			// tangents are only meaningful in combination with a texture mapping
+			tangents := make([][4]float32, 0)
+			if tangentIdx, hasTangents := prim.Attributes["TANGENT"]; hasTangents {
+				tangents, err = modeler.ReadTangent(doc, doc.Accessors[tangentIdx], nil)
+				if err != nil {
+					return nil, err
+				}
+			} else if len(texCoords) == len(positions) {
+				tangents = generateTangents(indices, positions, normals, texCoords)
+			}
+
 			var material scene.Material
 			if prim.Material != nil {
 				material = materials[*prim.Material]
 			}
 
+			for i := range positions {
+				positions[i] = transformPoint(sn.transform, positions[i])
+				normals[i] = transformNormal(normalMat, normals[i])
+			}
+			for i := range tangents {
+				tangents[i] = transformTangent(sn.transform, tangents[i])
+			}
+
+			// mirroring transforms turn counter-clockwise front faces clockwise, hence the winding is restored
+			mirrored := sn.transform.Det() < 0
+
 			for i := 0; i < len(indices); i += 3 {
-				triangle := scene.NewTriangle(
-					createVertex(uint(i), indices, positions, normals, texCoords),
-					createVertex(uint(i+1), indices, positions, normals, texCoords),
-					createVertex(uint(i+2), indices, positions, normals, texCoords),
-					material,
-				)
+				v0 := createVertex(uint(i), indices, positions, normals, texCoords, tangents)
+				v1 := createVertex(uint(i+1), indices, positions, normals, texCoords, tangents)
+				v2 := createVertex(uint(i+2), indices, positions, normals, texCoords, tangents)
+				if mirrored {
+					v1, v2 = v2, v1
+				}
+
+				triangle := scene.NewTriangle(v0, v1, v2, material)
 
 				triangles = append(triangles, triangle)
 			}
@@ -110,10 +156,11 @@ func loadTriangles(doc *gltf.Document, materials []scene.Material) ([]scene.Tria
 	return triangles, nil
 }
 
-func loadCameras(doc *gltf.Document) ([]scene.Camera, error) {
+func loadCameras(doc *gltf.Document, nodes []sceneNode) ([]scene.Camera, error) {
 	cameras := []scene.Camera{}
 
-	for _, node := range doc.Nodes {
+	for _, sn := range nodes {
+		node := sn.node
 		if node.Camera == nil {
 			continue
 		}
@@ -129,9 +176,7 @@ func loadCameras(doc *gltf.Document) ([]scene.Camera, error) {
 			yFov = float32(camInfo.Perspective.Yfov)
 		}
 
-		translation := node.TranslationOrDefault()
-		rotation := node.RotationOrDefault()
-		transform := createTransformMatrix(translation, rotation)
+		transform := createAffineTransformation(sn.transform)
 
 		cam := scene.NewCamera(aspectRatio, yFov, transform)
 
@@ -141,19 +186,22 @@ func loadCameras(doc *gltf.Document) ([]scene.Camera, error) {
 	return cameras, nil
 }
 
-func loadLightSources(doc *gltf.Document) ([]scene.Light, error) {
+// Returns the point lights & the direction towards the first directional light, which is only used to place the sun
+// of the sky
+func loadLightSources(doc *gltf.Document, nodes []sceneNode) ([]scene.Light, *primitive.Vec3, error) {
 	lightSources := []scene.Light{}
+	var sunDirection *primitive.Vec3
 
 	rawLightData, hasLightData := doc.Extensions[lightspunctual.ExtensionName]
+	// scenes without punctual lights are lit by their emitters & infinite lights only
 	if !hasLightData {
-		lightSources = append(lightSources, defaultLight)
-		return lightSources, nil
+		return lightSources, nil, nil
 	}
 
 	lights := rawLightData.(lightspunctual.Lights)
 
-	for _, node := range doc.Nodes {
-		rawExtensionData, isLight := node.Extensions[lightspunctual.ExtensionName]
+	for _, sn := range nodes {
+		rawExtensionData, isLight := sn.node.Extensions[lightspunctual.ExtensionName]
 		if !isLight {
 			continue
 		}
@@ -161,27 +209,31 @@ func loadLightSources(doc *gltf.Document) ([]scene.Light, error) {
 		lightIdx := rawExtensionData.(lightspunctual.LightIndex)
 		lightData := lights[lightIdx]
 
+		// directional lights shine along the -Z axis of their node
+		if lightData.Type == lightspunctual.TypeDirectional && sunDirection == nil {
+			axis := sn.transform.Col(2)
+			direction := primitive.Vec3{X: axis.X(), Y: axis.Y(), Z: axis.Z()}.Normalize()
+			sunDirection = &direction
+			continue
+		}
+
 		if lightData.Type != lightspunctual.TypePoint {
 			continue
 		}
 
-		lightTranslation := node.TranslationOrDefault()
+		lightOrigin := sn.transform.Col(3)
 		light := scene.NewLight(
-			primitive.Vec3{
-				X: float32(lightTranslation[0]),
-				Y: float32(lightTranslation[1]),
-				Z: float32(lightTranslation[2]),
-			},
+			primitive.Vec3{X: lightOrigin.X(), Y: lightOrigin.Y(), Z: lightOrigin.Z()},
 			primitive.FromSlice(*lightData.Color),
 			float32(*lightData.Intensity),
 		)
 		lightSources = append(lightSources, light)
 	}
 
-	return lightSources, nil
+	return lightSources, sunDirection, nil
 }
 
-func loadMaterials(doc *gltf.Document) []scene.Material {
+func loadMaterials(doc *gltf.Document, textures *textureLoader) ([]scene.Material, error) {
 	materials := make([]scene.Material, len(doc.Materials))
 
 	for i, m := range doc.Materials {
@@ -189,82 +241,348 @@ func loadMaterials(doc *gltf.Document) []scene.Material {
 			continue
 		}
 
-		var roughness float32 = 1.0
-		var metallicness float32 = 0
-		baseColor := primitive.ScalarColor{R: 1, G: 1, B: 1}
-		pbr := m.PBRMetallicRoughness
+		material, err := loadPBRMaterial(m, textures)
+		if err != nil {
+			return nil, err
+		}
+
+		materials[i] = material
+	}
+
+	return materials, nil
+}
+
+func loadPBRMaterial(m *gltf.Material, textures *textureLoader) (*scene.PBR, error) {
+	pbr := m.PBRMetallicRoughness
+	metallic := float32(pbr.MetallicFactorOrDefault())
+	roughness := float32(pbr.RoughnessFactorOrDefault())
+	pbrMaterial := scene.NewPBR(loadBaseColor(pbr), metallic, roughness)
+
+	baseColorTexture, err := textures.load(pbr.BaseColorTexture, true)
+	if err != nil {
+		return nil, err
+	}
+	pbrMaterial.SetBaseColorTexture(baseColorTexture)
+	pbrMaterial.SetAlpha(loadAlpha(pbr), createAlphaMode(m.AlphaMode), float32(m.AlphaCutoffOrDefault()))
+	pbrMaterial.SetDoubleSided(m.DoubleSided)
+
+	if m.NormalTexture != nil && m.NormalTexture.Index != nil {
+		normalTexture, err := textures.load(&gltf.TextureInfo{
+			Index:    *m.NormalTexture.Index,
+			TexCoord: m.NormalTexture.TexCoord,
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+		pbrMaterial.SetNormalTexture(normalTexture, float32(m.NormalTexture.ScaleOrDefault()))
+	}
+
+	metallicRoughnessTexture, err := textures.load(pbr.MetallicRoughnessTexture, false)
+	if err != nil {
+		return nil, err
+	}
+	pbrMaterial.SetMetallicRoughnessTexture(metallicRoughnessTexture)
+
+	if m.OcclusionTexture != nil && m.OcclusionTexture.Index != nil {
+		occlusionTexture, err := textures.load(&gltf.TextureInfo{
+			Index:    *m.OcclusionTexture.Index,
+			TexCoord: m.OcclusionTexture.TexCoord,
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+		pbrMaterial.SetOcclusionTexture(occlusionTexture, float32(m.OcclusionTexture.StrengthOrDefault()))
+	}
 
-		if pbr.BaseColorFactor != nil && len(pbr.BaseColorFactor) >= 3 {
-			baseColor.R = float32(pbr.BaseColorFactor[0])
-			baseColor.G = float32(pbr.BaseColorFactor[1])
-			baseColor.B = float32(pbr.BaseColorFactor[2])
+	if emission := loadEmission(m); !emission.IsBlack() {
+		emissiveTexture, err := textures.load(m.EmissiveTexture, true)
+		if err != nil {
+			return nil, err
 		}
+		pbrMaterial.SetEmission(emission, emissiveTexture)
+	}
+
+	if ext, ok := m.Extensions[ior.ExtensionName].(*ior.MaterialsIor); ok && ext.Ior != nil && *ext.Ior >= 1 {
+		pbrMaterial.SetIOR(*ext.Ior)
+	}
 
-		if pbr.MetallicFactor != nil {
-			metallicness = float32(*pbr.MetallicFactor)
+	if ext, ok := m.Extensions[transmission.ExtensionName].(*transmission.MaterialsTransmission); ok {
+		var transmissionFactor float32
+		if ext.TransmissionFactor != nil {
+			transmissionFactor = *ext.TransmissionFactor
 		}
-		if pbr.RoughnessFactor != nil {
-			roughness = float32(*pbr.RoughnessFactor)
+
+		transmissionTexture, err := textures.load(ext.TransmissionTexture, false)
+		if err != nil {
+			return nil, err
 		}
+		pbrMaterial.SetTransmission(transmissionFactor, transmissionTexture)
+	}
 
-		_, hasTransmission := m.Extensions[transmission.ExtensionName]
+	if ext, ok := m.Extensions[volume.ExtensionName].(*volume.MaterialsVolume); ok {
+		pbrMaterial.SetInterior(loadMedium(ext))
+	}
 
-		var material scene.Material
-		emissiveFactorVec := primitive.Vec3{
-			X: float32(m.EmissiveFactor[0]),
-			Y: float32(m.EmissiveFactor[1]),
-			Z: float32(m.EmissiveFactor[2]),
+	if ext, ok := m.Extensions[clearcoat.ExtensionName].(*clearcoat.MaterialsClearcoat); ok {
+		err := loadClearcoat(pbrMaterial, ext, textures)
+		if err != nil {
+			return nil, err
 		}
-		if emissiveFactorVec.LengthSquared() > 0.0 {
-			material = scene.NewEmissive(primitive.FromSlice(m.EmissiveFactor))
-		} else if hasTransmission {
-			material = scene.NewGlass(baseColor)
-		} else if metallicness < 1.0 {
-			material = scene.NewDiffuse(baseColor)
-		} else {
-			material = scene.NewMetal(baseColor, roughness)
+	}
+
+	if ext, ok := m.Extensions[sheen.ExtensionName].(*sheen.MaterialsSheen); ok {
+		err := loadSheen(pbrMaterial, ext, textures)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		materials[i] = material
+	if ext, ok := m.Extensions[specular.ExtensionName].(*specular.MaterialsSpecular); ok {
+		err := loadSpecular(pbrMaterial, ext, textures)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return materials
+	if ext, ok := m.Extensions[anisotropy.ExtensionName].(*anisotropy.MaterialsAnisotropy); ok {
+		err := loadAnisotropy(pbrMaterial, ext, textures)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ext, ok := m.Extensions[iridescence.ExtensionName].(*iridescence.MaterialsIridescence); ok {
+		err := loadIridescence(pbrMaterial, ext, textures)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// glTF has no notion of nested volumes, their priority is therefore read from the custom properties
+	if extras, ok := m.Extras.(map[string]any); ok {
+		if priority, ok := extras["priority"].(float64); ok {
+			pbrMaterial.SetPriority(int(priority))
+		}
+	}
+
+	return pbrMaterial, nil
 }
 
-func createVertex(idx uint, indices []uint32, positions, normals [][3]float32, texCoords [][2]float32) scene.Vertex {
-	edgeCoords := positions[indices[idx]]
-	edgeNormals := normals[indices[idx]]
-	var uv *primitive.Vec2
+func loadClearcoat(material *scene.PBR, ext *clearcoat.MaterialsClearcoat, textures *textureLoader) error {
+	var factor, roughness float32
+	if ext.ClearcoatFactor != nil {
+		factor = *ext.ClearcoatFactor
+	}
+	if ext.ClearcoatRoughnessFactor != nil {
+		roughness = *ext.ClearcoatRoughnessFactor
+	}
 
-	if len(texCoords) > int(idx) {
-		uvCoords := texCoords[idx]
-		uv = &primitive.Vec2{X: uvCoords[0], Y: uvCoords[1]}
+	texture, err := textures.load(ext.ClearcoatTexture, false)
+	if err != nil {
+		return err
+	}
+	roughnessTexture, err := textures.load(ext.ClearcoatRoughnessTexture, false)
+	if err != nil {
+		return err
+	}
+	material.SetClearcoat(factor, texture, roughness, roughnessTexture)
+
+	if ext.ClearcoatNormalTexture != nil && ext.ClearcoatNormalTexture.Index != nil {
+		normalTexture, err := textures.load(&gltf.TextureInfo{
+			Index:    *ext.ClearcoatNormalTexture.Index,
+			TexCoord: ext.ClearcoatNormalTexture.TexCoord,
+		}, false)
+		if err != nil {
+			return err
+		}
+		material.SetClearcoatNormalTexture(normalTexture, float32(ext.ClearcoatNormalTexture.ScaleOrDefault()))
 	}
 
-	return scene.Vertex{
-		Point:  primitive.Vec3{X: edgeCoords[0], Y: edgeCoords[1], Z: edgeCoords[2]},
-		Normal: primitive.Vec3{X: edgeNormals[0], Y: edgeNormals[1], Z: edgeNormals[2]}.Normalize(),
-		UV:     uv,
+	return nil
+}
+
+func loadSheen(material *scene.PBR, ext *sheen.MaterialsSheen, textures *textureLoader) error {
+	var color primitive.ScalarColor
+	if ext.SheenColorFactor != nil {
+		color = primitive.ScalarColor{
+			R: ext.SheenColorFactor[0],
+			G: ext.SheenColorFactor[1],
+			B: ext.SheenColorFactor[2],
+		}
+	}
+
+	var roughness float32
+	if ext.SheenRoughnessFactor != nil {
+		roughness = *ext.SheenRoughnessFactor
+	}
+
+	colorTexture, err := textures.load(ext.SheenColorTexture, true)
+	if err != nil {
+		return err
+	}
+	roughnessTexture, err := textures.load(ext.SheenRoughnessTexture, false)
+	if err != nil {
+		return err
+	}
+
+	material.SetSheen(color, colorTexture, roughness, roughnessTexture)
+	return nil
+}
+
+func loadSpecular(material *scene.PBR, ext *specular.MaterialsSpecular, textures *textureLoader) error {
+	texture, err := textures.load(ext.SpecularTexture, false)
+	if err != nil {
+		return err
+	}
+	colorTexture, err := textures.load(ext.SpecularColorTexture, true)
+	if err != nil {
+		return err
+	}
+
+	color := ext.SpecularColorFactorOrDefault()
+	material.SetSpecular(
+		ext.SpecularFactorOrDefault(),
+		texture,
+		primitive.ScalarColor{R: color[0], G: color[1], B: color[2]},
+		colorTexture,
+	)
+	return nil
+}
+
+func loadAnisotropy(material *scene.PBR, ext *anisotropy.MaterialsAnisotropy, textures *textureLoader) error {
+	var strength, rotation float32
+	if ext.AnisotropyStrength != nil {
+		strength = *ext.AnisotropyStrength
+	}
+	if ext.AnisotropyRotation != nil {
+		rotation = *ext.AnisotropyRotation
+	}
+
+	texture, err := textures.load(ext.AnisotropyTexture, false)
+	if err != nil {
+		return err
+	}
+
+	material.SetAnisotropy(strength, rotation, texture)
+	return nil
+}
+
+func loadIridescence(material *scene.PBR, ext *iridescence.MaterialsIridescence, textures *textureLoader) error {
+	var factor float32
+	if ext.IridescenceFactor != nil {
+		factor = *ext.IridescenceFactor
+	}
+
+	texture, err := textures.load(ext.IridescenceTexture, false)
+	if err != nil {
+		return err
+	}
+	thicknessTexture, err := textures.load(ext.IridescenceThicknessTexture, false)
+	if err != nil {
+		return err
+	}
+
+	material.SetIridescence(
+		factor,
+		texture,
+		ext.IridescenceIorOrDefault(),
+		ext.IridescenceThicknessMinimumOrDefault(),
+		ext.IridescenceThicknessMaximumOrDefault(),
+		thicknessTexture,
+	)
+	return nil
+}
+
+// The thickness only approximates the volume for rasterizers, as the traced geometry defines the actual path length.
+// A zero thickness, which the extension uses for thin-walled surfaces, only leaves out the medium: such surfaces &
+// those without the volume extension still refract like the boundary of a solid object, just without absorption.
+func loadMedium(ext *volume.MaterialsVolume) *scene.Medium {
+	if ext.ThicknessFactor == nil || *ext.ThicknessFactor <= 0 {
+		return nil
+	}
+	if ext.AttenuationDistance == nil || math.IsInf(float64(*ext.AttenuationDistance), 1) {
+		return nil // no absorption
+	}
+	// the distance has to be positive, invalid values are treated like a medium without absorption
+	if !(*ext.AttenuationDistance > 0) {
+		return nil
+	}
+
+	attenuationColor := primitive.ScalarColor{R: 1, G: 1, B: 1}
+	if ext.AttenuationColor != nil {
+		attenuationColor = primitive.ScalarColor{
+			R: ext.AttenuationColor[0],
+			G: ext.AttenuationColor[1],
+			B: ext.AttenuationColor[2],
+		}
+	}
+
+	return scene.NewMedium(attenuationColor, *ext.AttenuationDistance)
+}
+
+func loadBaseColor(pbr *gltf.PBRMetallicRoughness) primitive.ScalarColor {
+	baseColor := primitive.ScalarColor{R: 1, G: 1, B: 1}
+	if pbr.BaseColorFactor != nil && len(pbr.BaseColorFactor) >= 3 {
+		baseColor.R = float32(pbr.BaseColorFactor[0])
+		baseColor.G = float32(pbr.BaseColorFactor[1])
+		baseColor.B = float32(pbr.BaseColorFactor[2])
 	}
+	return baseColor
 }
 
-func createTransformMatrix(translation [3]float64, rotation [4]float64) primitive.AffineTransformation {
-	rotationMat := mgl32.Ident3()
+// Returns the alpha of the base color factor, which is opaque if no factor is given
+func loadAlpha(pbr *gltf.PBRMetallicRoughness) float32 {
+	if pbr.BaseColorFactor == nil {
+		return 1
+	}
+	return float32(pbr.BaseColorFactor[3])
+}
 
-	if !isEmptyRotation(rotation) {
-		quat := mgl32.Quat{
-			V: mgl32.Vec3{float32(rotation[0]), float32(rotation[1]), float32(rotation[2])},
-			W: float32(rotation[3]),
-		}.Normalize()
-		rotationMat = quat.Mat4().Mat3()
+func createAlphaMode(mode gltf.AlphaMode) scene.AlphaMode {
+	switch mode {
+	case gltf.AlphaMask:
+		return scene.ALPHA_MASK
+	case gltf.AlphaBlend:
+		return scene.ALPHA_BLEND
+	default:
+		return scene.ALPHA_OPAQUE
 	}
+}
 
-	return primitive.AffineTransformation{
-		Rotation:    rotationMat,
-		Translation: mgl32.Vec3{float32(translation[0]), float32(translation[1]), float32(translation[2])},
+// Returns the emissive factor scaled by the emissive strength, which lifts the [0,1] limit of the factor
+func loadEmission(m *gltf.Material) primitive.ScalarColor {
+	emission := primitive.FromSlice(m.EmissiveFactor)
+
+	if ext, ok := m.Extensions[emissivestrength.ExtensionName].(*emissivestrength.MaterialsEmissiveStrength); ok && ext.EmissiveStrength != nil {
+		emission = emission.MulScalar(*ext.EmissiveStrength)
 	}
+
+	return emission
 }
 
-func isEmptyRotation(rotation [4]float64) bool {
-	return rotation[0] == 0 && rotation[1] == 0 && rotation[2] == 0 && rotation[3] == 1
+func createVertex(idx uint, indices []uint32, positions, normals [][3]float32, texCoords [][2]float32, tangents [][4]float32) scene.Vertex {
+	edgeCoords := positions[indices[idx]]
+	edgeNormals := normals[indices[idx]]
+	var uv *primitive.Vec2
+
+	if len(texCoords) > int(indices[idx]) {
+		uvCoords := texCoords[indices[idx]]
+		uv = &primitive.Vec2{X: uvCoords[0], Y: uvCoords[1]}
+	}
+
+	var tangent *scene.Tangent
+	if len(tangents) > int(indices[idx]) {
+		t := tangents[indices[idx]]
+		tangent = &scene.Tangent{
+			Direction:  primitive.Vec3{X: t[0], Y: t[1], Z: t[2]},
+			Handedness: t[3],
+		}
+	}
+
+	return scene.Vertex{
+		Point:   primitive.Vec3{X: edgeCoords[0], Y: edgeCoords[1], Z: edgeCoords[2]},
+		Normal:  primitive.Vec3{X: edgeNormals[0], Y: edgeNormals[1], Z: edgeNormals[2]}.Normalize(),
+		UV:      uv,
+		Tangent: tangent,
+	}
 }
@@ -0,0 +1,37 @@
+package imprt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ruegerj/raytracing/scene/gltf-ext/volume"
+)
+
+func TestLoadMediumRejectsInvalidVolumes(t *testing.T) {
+	valueOf := func(value float32) *float32 {
+		return &value
+	}
+	color := &[3]float32{0.5, 0.5, 0.5}
+
+	tests := []struct {
+		name       string
+		ext        volume.MaterialsVolume
+		wantMedium bool
+	}{
+		{"absorbing volume", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationDistance: valueOf(2), AttenuationColor: color}, true},
+		{"default color", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationDistance: valueOf(2)}, true},
+		{"thin walled", volume.MaterialsVolume{ThicknessFactor: valueOf(0), AttenuationDistance: valueOf(2), AttenuationColor: color}, false},
+		{"no thickness", volume.MaterialsVolume{AttenuationDistance: valueOf(2), AttenuationColor: color}, false},
+		{"no attenuation distance", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationColor: color}, false},
+		{"infinite attenuation distance", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationDistance: valueOf(float32(math.Inf(1))), AttenuationColor: color}, false},
+		{"zero attenuation distance", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationDistance: valueOf(0), AttenuationColor: color}, false},
+		{"negative attenuation distance", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationDistance: valueOf(-1), AttenuationColor: color}, false},
+		{"NaN attenuation distance", volume.MaterialsVolume{ThicknessFactor: valueOf(1), AttenuationDistance: valueOf(float32(math.NaN())), AttenuationColor: color}, false},
+	}
+
+	for _, tt := range tests {
+		if medium := loadMedium(&tt.ext); (medium != nil) != tt.wantMedium {
+			t.Errorf("%s: medium = %v, want a medium: %t", tt.name, medium, tt.wantMedium)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package imprt
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/qmuntal/gltf"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+type sceneNode struct {
+	node      *gltf.Node
+	transform mgl32.Mat4
+}
+
+// Walks the node hierarchy of the active scene and composes the world transform of each visited node
+func collectSceneNodes(doc *gltf.Document) []sceneNode {
+	nodes := make([]sceneNode, 0, len(doc.Nodes))
+
+	var visit func(nodeIdx int, parentTransform mgl32.Mat4)
+	visit = func(nodeIdx int, parentTransform mgl32.Mat4) {
+		node := doc.Nodes[nodeIdx]
+		transform := parentTransform.Mul4(localTransform(node))
+		nodes = append(nodes, sceneNode{node: node, transform: transform})
+
+		for _, childIdx := range node.Children {
+			visit(childIdx, transform)
+		}
+	}
+
+	for _, rootIdx := range rootNodes(doc) {
+		visit(rootIdx, mgl32.Ident4())
+	}
+
+	return nodes
+}
+
+func rootNodes(doc *gltf.Document) []int {
+	if len(doc.Scenes) > 0 {
+		sceneIdx := 0
+		if doc.Scene != nil {
+			sceneIdx = *doc.Scene
+		}
+		return doc.Scenes[sceneIdx].Nodes
+	}
+
+	// without any scene, every node which isn't referenced as a child is treated as root
+	isChild := make([]bool, len(doc.Nodes))
+	for _, node := range doc.Nodes {
+		for _, childIdx := range node.Children {
+			isChild[childIdx] = true
+		}
+	}
+
+	roots := make([]int, 0)
+	for i := range doc.Nodes {
+		if !isChild[i] {
+			roots = append(roots, i)
+		}
+	}
+
+	return roots
+}
+
+func localTransform(node *gltf.Node) mgl32.Mat4 {
+	matrix := node.MatrixOrDefault()
+	if matrix != gltf.DefaultMatrix {
+		// both glTF and mathgl store matrices in column-major order
+		var transform mgl32.Mat4
+		for i, value := range matrix {
+			transform[i] = float32(value)
+		}
+		return transform
+	}
+
+	t := node.TranslationOrDefault()
+	r := node.RotationOrDefault()
+	s := node.ScaleOrDefault()
+
+	translation := mgl32.Translate3D(float32(t[0]), float32(t[1]), float32(t[2]))
+	rotation := mgl32.Quat{
+		V: mgl32.Vec3{float32(r[0]), float32(r[1]), float32(r[2])},
+		W: float32(r[3]),
+	}.Normalize().Mat4()
+	scale := mgl32.Scale3D(float32(s[0]), float32(s[1]), float32(s[2]))
+
+	return translation.Mul4(rotation).Mul4(scale)
+}
+
+func transformPoint(transform mgl32.Mat4, point [3]float32) [3]float32 {
+	transformed := transform.Mul4x1(mgl32.Vec4{point[0], point[1], point[2], 1})
+	return [3]float32{transformed.X(), transformed.Y(), transformed.Z()}
+}
+
+// Normals have to be transformed with the inverse-transpose in order to stay perpendicular under non-uniform scaling
+func normalMatrix(transform mgl32.Mat4) mgl32.Mat3 {
+	return transform.Mat3().Inv().Transpose()
+}
+
+func transformNormal(normalMat mgl32.Mat3, normal [3]float32) [3]float32 {
+	transformed := normalMat.Mul3x1(mgl32.Vec3{normal[0], normal[1], normal[2]})
+	return [3]float32{transformed.X(), transformed.Y(), transformed.Z()}
+}
+
+// Tangents lie in the surface & are transformed like positions, a mirroring transform flips the handedness
+func transformTangent(transform mgl32.Mat4, tangent [4]float32) [4]float32 {
+	basis := transform.Mat3()
+	transformed := basis.Mul3x1(mgl32.Vec3{tangent[0], tangent[1], tangent[2]}).Normalize()
+
+	handedness := tangent[3]
+	if basis.Det() < 0 {
+		handedness = -handedness
+	}
+
+	return [4]float32{transformed.X(), transformed.Y(), transformed.Z(), handedness}
+}
+
+// Splits a world transform into translation and a scale-free rotation, as used by cameras
+func createAffineTransformation(transform mgl32.Mat4) primitive.AffineTransformation {
+	basis := transform.Mat3()
+	rotation := mgl32.Mat3FromCols(
+		basis.Col(0).Normalize(),
+		basis.Col(1).Normalize(),
+		basis.Col(2).Normalize(),
+	)
+
+	return primitive.AffineTransformation{
+		Rotation:    rotation,
+		Translation: transform.Col(3).Vec3(),
+	}
+}
@@ -0,0 +1,113 @@
+package imprt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+func TestCollectSceneNodesComposesTransforms(t *testing.T) {
+	halfSqrt2 := math.Sqrt2 / 2
+	doc := &gltf.Document{
+		Nodes: []*gltf.Node{
+			{Name: "root", Translation: [3]float64{1, 0, 0}, Children: []int{1}},
+			// rotated by 90° around Z & scaled
+			{Name: "rotated", Rotation: [4]float64{0, 0, halfSqrt2, halfSqrt2}, Scale: [3]float64{2, 2, 2}, Children: []int{2}},
+			// translation by (0, 1, 0) given as column-major matrix
+			{Name: "matrix", Matrix: [16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1}},
+		},
+	}
+
+	nodes := collectSceneNodes(doc)
+
+	want := map[string]mgl32.Vec3{
+		"root":    {1, 0, 0},
+		"rotated": {1, 0, 0},
+		"matrix":  {-1, 0, 0},
+	}
+	if len(nodes) != len(want) {
+		t.Fatalf("collected %d nodes, want %d", len(nodes), len(want))
+	}
+	for _, sn := range nodes {
+		origin := mgl32.Vec3(transformPoint(sn.transform, [3]float32{0, 0, 0}))
+		if origin.Sub(want[sn.node.Name]).Len() > 1e-5 {
+			t.Errorf("origin of node %s = %v, want %v", sn.node.Name, origin, want[sn.node.Name])
+		}
+	}
+
+	// the child inherits the rotation & scale of its parent
+	matrix := nodes[2].transform
+	if x := mgl32.Vec3(transformPoint(matrix, [3]float32{1, 0, 0})); x.Sub(mgl32.Vec3{-1, 2, 0}).Len() > 1e-5 {
+		t.Errorf("(1, 0, 0) in node matrix = %v, want (-1, 2, 0)", x)
+	}
+}
+
+func TestCollectSceneNodesPicksRoots(t *testing.T) {
+	nodes := []*gltf.Node{{Name: "a", Children: []int{1}}, {Name: "b"}, {Name: "c"}}
+
+	tests := []struct {
+		name string
+		doc  *gltf.Document
+		want []string
+	}{
+		{"default scene", &gltf.Document{Nodes: nodes, Scenes: []*gltf.Scene{{Nodes: []int{2}}, {Nodes: []int{0}}}}, []string{"c"}},
+		{"active scene", &gltf.Document{Nodes: nodes, Scenes: []*gltf.Scene{{Nodes: []int{2}}, {Nodes: []int{0}}}, Scene: gltf.Index(1)}, []string{"a", "b"}},
+		// every node which isn't a child is a root
+		{"no scene", &gltf.Document{Nodes: nodes}, []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		collected := collectSceneNodes(tt.doc)
+		names := make([]string, len(collected))
+		for i, sn := range collected {
+			names[i] = sn.node.Name
+		}
+
+		if len(names) != len(tt.want) {
+			t.Errorf("%s: collected %v, want %v", tt.name, names, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("%s: collected %v, want %v", tt.name, names, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestLoadTrianglesKeepsWindingOfMirroredNodes(t *testing.T) {
+	for _, scale := range [][3]float64{{1, 1, 1}, {-1, 1, 1}, {2, -1, 3}, {-1, -1, -1}} {
+		doc := gltf.NewDocument()
+		doc.Meshes = []*gltf.Mesh{{
+			Primitives: []*gltf.Primitive{{
+				Indices: gltf.Index(modeler.WriteIndices(doc, []uint16{0, 1, 2})),
+				Attributes: gltf.PrimitiveAttributes{
+					// counter-clockwise as seen from the normal
+					"POSITION": modeler.WritePosition(doc, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}),
+					"NORMAL":   modeler.WriteNormal(doc, [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}}),
+				},
+			}},
+		}}
+		doc.Nodes = []*gltf.Node{{Mesh: gltf.Index(0), Scale: scale}}
+		doc.Scenes[0].Nodes = []int{0}
+
+		triangles, err := loadTriangles(doc, collectSceneNodes(doc), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(triangles) != 1 {
+			t.Fatalf("scale %v: loaded %d triangles, want 1", scale, len(triangles))
+		}
+
+		// the front face, where the vertices appear counter-clockwise, still has to face the vertex normals
+		tr := triangles[0]
+		front := tr.V1.Point.Sub(tr.V0.Point).Cross(tr.V2.Point.Sub(tr.V0.Point))
+		if front.Dot(tr.V0.Normal) <= 0 {
+			t.Errorf("scale %v: front face %v opposes the normal %v", scale, front, tr.V0.Normal)
+		}
+	}
+}
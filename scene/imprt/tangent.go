@@ -0,0 +1,81 @@
+package imprt
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Generates per vertex tangents pointing along increasing U: the face tangents are accumulated per vertex, weighted
+// by their corner angle & orthogonalized against the vertex normal. The handedness is chosen such that
+// cross(normal, tangent) * handedness points towards decreasing V, as glTF flips the V axis compared to the texture
+// space of the normal map. Unlike MikkTSpace, vertices are neither split nor welded, hence normal maps baked against
+// MikkTSpace tangents may deviate slightly, especially along UV seams.
+func generateTangents(indices []uint32, positions, normals [][3]float32, texCoords [][2]float32) [][4]float32 {
+	tangents := make([]mgl32.Vec3, len(positions))
+	bitangents := make([]mgl32.Vec3, len(positions))
+
+	for i := 0; i+2 < len(indices); i += 3 {
+		corners := [3]uint32{indices[i], indices[i+1], indices[i+2]}
+
+		p0, p1, p2 := mgl32.Vec3(positions[corners[0]]), mgl32.Vec3(positions[corners[1]]), mgl32.Vec3(positions[corners[2]])
+		uv0, uv1, uv2 := mgl32.Vec2(texCoords[corners[0]]), mgl32.Vec2(texCoords[corners[1]]), mgl32.Vec2(texCoords[corners[2]])
+
+		edge1, edge2 := p1.Sub(p0), p2.Sub(p0)
+		duv1, duv2 := uv1.Sub(uv0), uv2.Sub(uv0)
+
+		det := duv1.X()*duv2.Y() - duv2.X()*duv1.Y()
+		if det == 0 {
+			continue // degenerated texture mapping
+		}
+
+		tangent := edge1.Mul(duv2.Y()).Sub(edge2.Mul(duv1.Y())).Mul(1 / det)
+		bitangent := edge2.Mul(duv1.X()).Sub(edge1.Mul(duv2.X())).Mul(-1 / det)
+
+		points := [3]mgl32.Vec3{p0, p1, p2}
+		for c, vertexIdx := range corners {
+			angle := cornerAngle(points[c], points[(c+1)%3], points[(c+2)%3])
+			tangents[vertexIdx] = tangents[vertexIdx].Add(tangent.Mul(angle))
+			bitangents[vertexIdx] = bitangents[vertexIdx].Add(bitangent.Mul(angle))
+		}
+	}
+
+	result := make([][4]float32, len(positions))
+	for i := range positions {
+		normal := mgl32.Vec3(normals[i]).Normalize()
+		tangent := tangents[i].Sub(normal.Mul(normal.Dot(tangents[i])))
+
+		if tangent.Len() == 0 {
+			// no usable texture mapping, any direction perpendicular to the normal will do
+			tangent = perpendicular(normal)
+		}
+		tangent = tangent.Normalize()
+
+		var handedness float32 = 1
+		if normal.Cross(tangent).Dot(bitangents[i]) < 0 {
+			handedness = -1
+		}
+
+		result[i] = [4]float32{tangent.X(), tangent.Y(), tangent.Z(), handedness}
+	}
+
+	return result
+}
+
+func cornerAngle(corner, next, previous mgl32.Vec3) float32 {
+	a := next.Sub(corner)
+	b := previous.Sub(corner)
+	if a.Len() == 0 || b.Len() == 0 {
+		return 0
+	}
+
+	cosAngle := mgl32.Clamp(a.Normalize().Dot(b.Normalize()), -1, 1)
+	return float32(math.Acos(float64(cosAngle)))
+}
+
+func perpendicular(v mgl32.Vec3) mgl32.Vec3 {
+	if math.Abs(float64(v.X())) > 0.9 {
+		return v.Cross(mgl32.Vec3{0, 1, 0})
+	}
+	return v.Cross(mgl32.Vec3{1, 0, 0})
+}
@@ -0,0 +1,48 @@
+package imprt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Unit quad in the XY plane facing +Z, split into two triangles
+var quadIndices = []uint32{0, 1, 2, 0, 2, 3}
+var quadPositions = [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}}
+var quadNormals = [][3]float32{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}}
+
+func TestGenerateTangents(t *testing.T) {
+	tests := []struct {
+		name      string
+		texCoords [][2]float32
+		want      [4]float32
+	}{
+		// V points down in glTF, hence towards -Y on the quad
+		{"regular mapping", [][2]float32{{0, 1}, {1, 1}, {1, 0}, {0, 0}}, [4]float32{1, 0, 0, 1}},
+		{"mirrored along U", [][2]float32{{1, 1}, {0, 1}, {0, 0}, {1, 0}}, [4]float32{-1, 0, 0, -1}},
+		{"mirrored along V", [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, [4]float32{1, 0, 0, -1}},
+		{"rotated mapping", [][2]float32{{1, 1}, {1, 0}, {0, 0}, {0, 1}}, [4]float32{0, -1, 0, 1}},
+	}
+
+	for _, tt := range tests {
+		tangents := generateTangents(quadIndices, quadPositions, quadNormals, tt.texCoords)
+		for i, tangent := range tangents {
+			if !mgl32.Vec4(tangent).ApproxEqualThreshold(mgl32.Vec4(tt.want), 1e-5) {
+				t.Errorf("%s: tangent of vertex %d = %v, want %v", tt.name, i, tangent, tt.want)
+			}
+		}
+	}
+}
+
+func TestGenerateTangentsWithoutTextureMapping(t *testing.T) {
+	// all vertices share the same texture coordinates
+	texCoords := make([][2]float32, len(quadPositions))
+
+	for i, tangent := range generateTangents(quadIndices, quadPositions, quadNormals, texCoords) {
+		direction := mgl32.Vec3{tangent[0], tangent[1], tangent[2]}
+		if math.Abs(float64(direction.Len()-1)) > 1e-5 || direction.Dot(mgl32.Vec3(quadNormals[i])) != 0 {
+			t.Errorf("tangent of vertex %d = %v, want a unit vector perpendicular to the normal", i, tangent)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package imprt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+	"github.com/ruegerj/raytracing/scene"
+)
+
+type textureKey struct {
+	index int
+	srgb  bool
+}
+
+// Loads the textures referenced by materials, each image is only decoded once even if shared by several textures
+type textureLoader struct {
+	doc      *gltf.Document
+	dir      string
+	images   map[int]image.Image
+	textures map[textureKey]*scene.Texture
+	// textures already reported as unsupported, such that each is only reported once
+	unsupported map[int]bool
+}
+
+func newTextureLoader(doc *gltf.Document, dir string) *textureLoader {
+	return &textureLoader{
+		doc:         doc,
+		dir:         dir,
+		images:      make(map[int]image.Image),
+		textures:    make(map[textureKey]*scene.Texture),
+		unsupported: make(map[int]bool),
+	}
+}
+
+// Returns the referenced texture, nil if there is no reference. Color textures have to be decoded from sRGB.
+func (tl *textureLoader) load(info *gltf.TextureInfo, srgb bool) (*scene.Texture, error) {
+	if info == nil {
+		return nil, nil
+	}
+	// only the first set of texture coordinates is imported, textures mapped onto other sets are left out
+	if info.TexCoord != 0 {
+		if !tl.unsupported[info.Index] {
+			log.Printf("texture %d: unsupported texture coordinate set TEXCOORD_%d, texture is ignored\n", info.Index, info.TexCoord)
+			tl.unsupported[info.Index] = true
+		}
+		return nil, nil
+	}
+
+	key := textureKey{index: info.Index, srgb: srgb}
+	if texture, ok := tl.textures[key]; ok {
+		return texture, nil
+	}
+
+	if info.Index < 0 || info.Index >= len(tl.doc.Textures) {
+		return nil, fmt.Errorf("texture %d: index out of range", info.Index)
+	}
+	gltfTexture := tl.doc.Textures[info.Index]
+	if gltfTexture.Source == nil {
+		return nil, fmt.Errorf("texture %d: no image source", info.Index)
+	}
+
+	img, err := tl.loadImage(*gltfTexture.Source)
+	if err != nil {
+		return nil, fmt.Errorf("texture %d: %w", info.Index, err)
+	}
+
+	wrapS, wrapT, filter := scene.WRAP_REPEAT, scene.WRAP_REPEAT, scene.FILTER_LINEAR
+	if gltfTexture.Sampler != nil {
+		samplerIdx := *gltfTexture.Sampler
+		if samplerIdx < 0 || samplerIdx >= len(tl.doc.Samplers) {
+			return nil, fmt.Errorf("texture %d: sampler %d out of range", info.Index, samplerIdx)
+		}
+		sampler := tl.doc.Samplers[samplerIdx]
+		wrapS = createWrapMode(sampler.WrapS)
+		wrapT = createWrapMode(sampler.WrapT)
+		filter = createFilter(sampler.MagFilter, sampler.MinFilter)
+	}
+
+	texture := scene.NewTexture(img, srgb, wrapS, wrapT, filter)
+	tl.textures[key] = texture
+
+	return texture, nil
+}
+
+func (tl *textureLoader) loadImage(imageIdx int) (image.Image, error) {
+	if img, ok := tl.images[imageIdx]; ok {
+		return img, nil
+	}
+
+	if imageIdx < 0 || imageIdx >= len(tl.doc.Images) {
+		return nil, fmt.Errorf("image %d: index out of range", imageIdx)
+	}
+	data, err := tl.readImageData(tl.doc.Images[imageIdx])
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", imageIdx, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", imageIdx, err)
+	}
+
+	tl.images[imageIdx] = img
+	return img, nil
+}
+
+func (tl *textureLoader) readImageData(img *gltf.Image) ([]byte, error) {
+	if img.BufferView != nil {
+		if *img.BufferView < 0 || *img.BufferView >= len(tl.doc.BufferViews) {
+			return nil, fmt.Errorf("buffer view %d out of range", *img.BufferView)
+		}
+		return modeler.ReadBufferView(tl.doc, tl.doc.BufferViews[*img.BufferView])
+	}
+	if img.IsEmbeddedResource() {
+		return img.MarshalData()
+	}
+	if img.URI == "" {
+		return nil, errors.New("image without uri or buffer view")
+	}
+
+	// uris are relative to the glTF file & may contain escaped characters
+	path, err := url.PathUnescape(img.URI)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(tl.dir, filepath.FromSlash(path)))
+}
+
+func createWrapMode(mode gltf.WrappingMode) scene.WrapMode {
+	switch mode {
+	case gltf.WrapClampToEdge:
+		return scene.WRAP_CLAMP_TO_EDGE
+	case gltf.WrapMirroredRepeat:
+		return scene.WRAP_MIRRORED_REPEAT
+	default:
+		return scene.WRAP_REPEAT
+	}
+}
+
+// Without ray differentials there is no notion of minification, the mag filter therefore takes precedence & the
+// min filter only decides if it is undefined (mipmap levels are ignored)
+func createFilter(magFilter gltf.MagFilter, minFilter gltf.MinFilter) scene.TextureFilter {
+	switch magFilter {
+	case gltf.MagNearest:
+		return scene.FILTER_NEAREST
+	case gltf.MagLinear:
+		return scene.FILTER_LINEAR
+	}
+
+	switch minFilter {
+	case gltf.MinNearest, gltf.MinNearestMipMapNearest, gltf.MinNearestMipMapLinear:
+		return scene.FILTER_NEAREST
+	default:
+		return scene.FILTER_LINEAR
+	}
+}
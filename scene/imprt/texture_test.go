@@ -0,0 +1,118 @@
+package imprt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/qmuntal/gltf"
+	"github.com/ruegerj/raytracing/scene"
+)
+
+// Returns a 1x1 PNG embedded as a data uri
+func embeddedImage(t *testing.T) *gltf.Image {
+	t.Helper()
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	return &gltf.Image{URI: "data:image/png;base64," + base64.StdEncoding.EncodeToString(data.Bytes())}
+}
+
+func TestTextureLoaderLoad(t *testing.T) {
+	doc := &gltf.Document{
+		Images:   []*gltf.Image{embeddedImage(t), {BufferView: gltf.Index(3)}},
+		Samplers: []*gltf.Sampler{{WrapS: gltf.WrapClampToEdge, MagFilter: gltf.MagNearest}},
+		Textures: []*gltf.Texture{
+			{Source: gltf.Index(0), Sampler: gltf.Index(0)},
+			{Source: gltf.Index(0), Sampler: gltf.Index(1)},
+			{Source: gltf.Index(2)},
+			{Source: gltf.Index(1)},
+			{},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		info        *gltf.TextureInfo
+		wantTexture bool
+		wantErr     bool
+	}{
+		{"no reference", nil, false, false},
+		{"valid texture", &gltf.TextureInfo{Index: 0}, true, false},
+		// only reported, not an error
+		{"second texture coordinate set", &gltf.TextureInfo{Index: 0, TexCoord: 1}, false, false},
+		{"texture out of range", &gltf.TextureInfo{Index: 5}, false, true},
+		{"negative texture index", &gltf.TextureInfo{Index: -1}, false, true},
+		{"sampler out of range", &gltf.TextureInfo{Index: 1}, false, true},
+		{"image out of range", &gltf.TextureInfo{Index: 2}, false, true},
+		{"buffer view out of range", &gltf.TextureInfo{Index: 3}, false, true},
+		{"no image source", &gltf.TextureInfo{Index: 4}, false, true},
+	}
+
+	for _, tt := range tests {
+		texture, err := newTextureLoader(doc, t.TempDir()).load(tt.info, true)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want an error: %t", tt.name, err, tt.wantErr)
+		}
+		if (texture != nil) != tt.wantTexture {
+			t.Errorf("%s: texture = %v, want a texture: %t", tt.name, texture, tt.wantTexture)
+		}
+	}
+}
+
+func TestTextureLoaderReusesTextures(t *testing.T) {
+	doc := &gltf.Document{
+		Images:   []*gltf.Image{embeddedImage(t)},
+		Textures: []*gltf.Texture{{Source: gltf.Index(0)}},
+	}
+	loader := newTextureLoader(doc, t.TempDir())
+
+	color, _ := loader.load(&gltf.TextureInfo{Index: 0}, true)
+	again, _ := loader.load(&gltf.TextureInfo{Index: 0}, true)
+	data, _ := loader.load(&gltf.TextureInfo{Index: 0}, false)
+	if color == nil || color != again {
+		t.Errorf("loading a texture twice returned %p & %p, want the same texture", color, again)
+	}
+	// the same image decoded with & without sRGB results in two textures
+	if data == color {
+		t.Errorf("sRGB & linear texture are the same")
+	}
+	if len(loader.images) != 1 {
+		t.Errorf("decoded %d images, want 1", len(loader.images))
+	}
+}
+
+func TestCreateWrapModeAndFilter(t *testing.T) {
+	wrapModes := map[gltf.WrappingMode]scene.WrapMode{
+		gltf.WrapRepeat:         scene.WRAP_REPEAT,
+		gltf.WrapClampToEdge:    scene.WRAP_CLAMP_TO_EDGE,
+		gltf.WrapMirroredRepeat: scene.WRAP_MIRRORED_REPEAT,
+	}
+	for mode, want := range wrapModes {
+		if got := createWrapMode(mode); got != want {
+			t.Errorf("createWrapMode(%v) = %d, want %d", mode, got, want)
+		}
+	}
+
+	filters := []struct {
+		mag  gltf.MagFilter
+		min  gltf.MinFilter
+		want scene.TextureFilter
+	}{
+		{gltf.MagNearest, gltf.MinLinear, scene.FILTER_NEAREST},
+		{gltf.MagLinear, gltf.MinNearest, scene.FILTER_LINEAR},
+		// an undefined mag filter falls back to the min filter
+		{gltf.MagUndefined, gltf.MinNearestMipMapLinear, scene.FILTER_NEAREST},
+		{gltf.MagUndefined, gltf.MinLinearMipMapNearest, scene.FILTER_LINEAR},
+		{gltf.MagUndefined, gltf.MinUndefined, scene.FILTER_LINEAR},
+	}
+	for _, tt := range filters {
+		if got := createFilter(tt.mag, tt.min); got != tt.want {
+			t.Errorf("createFilter(%v, %v) = %d, want %d", tt.mag, tt.min, got, tt.want)
+		}
+	}
+}
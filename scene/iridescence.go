@@ -0,0 +1,128 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Films thinner than this (in nm) fade into the surrounding medium, as they can't produce any interference
+const thin_film_fade_thickness float32 = 30
+
+// Thin dielectric film on top of a surface, whose interference colors the reflection depending on the viewing angle
+// (Belcour & Barla, "A Practical Extension to Microfacet Theory for the Modeling of Varying Iridescence")
+type thinFilm struct {
+	factor float32
+	ior    float32
+	// Thickness of the film in nanometers
+	thickness float32
+}
+
+// Returns the reflectance of a base with the given F0 seen through the film from a medium with the outside IOR
+func (f thinFilm) fresnel(cosTheta, outsideIOR float32, baseF0 primitive.ScalarColor) primitive.ScalarColor {
+	// the film vanishes into the outside medium as its thickness approaches zero
+	filmIOR := outsideIOR + (f.ior-outsideIOR)*smoothstep(0, thin_film_fade_thickness, f.thickness)
+
+	// angle inside the film (Snell's law), which is fully reflective at total internal reflection
+	sin2ThetaFilm := (outsideIOR / filmIOR) * (outsideIOR / filmIOR) * (1 - cosTheta*cosTheta)
+	if sin2ThetaFilm >= 1 {
+		return primitive.ScalarColor{R: 1, G: 1, B: 1}
+	}
+	cosThetaFilm := float32(math.Sqrt(float64(1 - sin2ThetaFilm)))
+
+	// first interface between the outside & the film
+	r12 := schlickReflectance(iorToFresnel0(filmIOR, outsideIOR), cosTheta)
+	t121 := 1 - r12
+	var phi12 float32
+	if filmIOR < outsideIOR {
+		phi12 = math.Pi
+	}
+	phi21 := math.Pi - phi12
+
+	// optical path difference between the interfering rays
+	opd := 2 * filmIOR * f.thickness * cosThetaFilm
+
+	// second interface between the film & the base, evaluated per channel
+	channel := func(f0 float32) (r23, phi23 float32) {
+		baseIOR := fresnel0ToIOR(min(max(f0, 0), 0.9999))
+		r23 = schlickReflectance(iorToFresnel0(baseIOR, filmIOR), cosThetaFilm)
+		if baseIOR < filmIOR {
+			phi23 = math.Pi
+		}
+		return r23, phi23
+	}
+	r23R, phi23R := channel(baseF0.R)
+	r23G, phi23G := channel(baseF0.G)
+	r23B, phi23B := channel(baseF0.B)
+
+	// reflectance of the airy sum, split into the DC term & the first two spectral harmonics
+	var reflectance [3]float32
+	var cm, r123 [3]float32
+	for i, r23 := range [3]float32{r23R, r23G, r23B} {
+		r := min(max(r12*r23, 1e-5), 0.9999)
+		rs := t121 * t121 * r23 / (1 - r)
+		reflectance[i] = r12 + rs
+		cm[i] = rs - t121
+		r123[i] = float32(math.Sqrt(float64(r)))
+	}
+
+	phi := [3]float32{phi21 + phi23R, phi21 + phi23G, phi21 + phi23B}
+	for m := float32(1); m <= 2; m++ {
+		sensitivity := evalSensitivity(m*opd, [3]float32{m * phi[0], m * phi[1], m * phi[2]})
+		for i := range reflectance {
+			cm[i] *= r123[i]
+			reflectance[i] += cm[i] * 2 * sensitivity[i]
+		}
+	}
+
+	// out of gamut colors may produce negative values
+	return primitive.ScalarColor{
+		R: max(reflectance[0], 0),
+		G: max(reflectance[1], 0),
+		B: max(reflectance[2], 0),
+	}
+}
+
+// Fourier transform of the CIE XYZ color matching functions, approximated by gaussians & converted to linear sRGB
+func evalSensitivity(opd float32, shift [3]float32) [3]float32 {
+	phase := 2 * math.Pi * float64(opd) * 1e-9
+	val := [3]float64{5.4856e-13, 4.4201e-13, 5.2481e-13}
+	pos := [3]float64{1.6810e+06, 1.7953e+06, 2.2084e+06}
+	variance := [3]float64{4.3278e+09, 9.3046e+09, 6.6121e+09}
+
+	var xyz [3]float64
+	for i := range xyz {
+		xyz[i] = val[i] * math.Sqrt(2*math.Pi*variance[i]) * math.Cos(pos[i]*phase+float64(shift[i])) *
+			math.Exp(-phase*phase*variance[i])
+	}
+	xyz[0] += 9.7470e-14 * math.Sqrt(2*math.Pi*4.5282e+09) * math.Cos(2.2399e+06*phase+float64(shift[0])) *
+		math.Exp(-4.5282e+09*phase*phase)
+	for i := range xyz {
+		xyz[i] /= 1.0685e-7
+	}
+
+	return [3]float32{
+		float32(3.2404542*xyz[0] - 1.5371385*xyz[1] - 0.4985314*xyz[2]),
+		float32(-0.9692660*xyz[0] + 1.8760108*xyz[1] + 0.0415560*xyz[2]),
+		float32(0.0556434*xyz[0] - 0.2040259*xyz[1] + 1.0572252*xyz[2]),
+	}
+}
+
+func schlickReflectance(f0, cosTheta float32) float32 {
+	return f0 + (1-f0)*schlickWeight(cosTheta)
+}
+
+func iorToFresnel0(transmittedIOR, incidentIOR float32) float32 {
+	r := (transmittedIOR - incidentIOR) / (transmittedIOR + incidentIOR)
+	return r * r
+}
+
+func fresnel0ToIOR(f0 float32) float32 {
+	sqrtF0 := float32(math.Sqrt(float64(f0)))
+	return (1 + sqrtF0) / (1 - sqrtF0)
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := min(max((x-edge0)/(edge1-edge0), 0), 1)
+	return t * t * (3 - 2*t)
+}
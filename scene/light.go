@@ -1,6 +1,9 @@
 package scene
 
-import "github.com/ruegerj/raytracing/primitive"
+import (
+	"github.com/ruegerj/raytracing/config"
+	"github.com/ruegerj/raytracing/primitive"
+)
 
 type Light struct {
 	Origin    primitive.Vec3
@@ -15,3 +18,22 @@ func NewLight(origin primitive.Vec3, color primitive.ScalarColor, intensity floa
 		Intensity: intensity,
 	}
 }
+
+// Returns the light arriving at the given distance, attenuated by constant, linear & quadratic falloff
+func (l Light) IlluminationAt(distance float32) primitive.ScalarColor {
+	attenuation := config.DEPTH_LIGHT_A_FACTOR +
+		config.DEPTH_LIGHT_B_FACTOR*distance +
+		config.DEPTH_LIGHT_C_FACTOR*distance*distance
+
+	return l.Color.MulScalar(l.Intensity / attenuation)
+}
+
+// Light at an infinite distance surrounding the scene, which is reached by every ray leaving it
+type InfiniteLight interface {
+	// Radiance arriving from the given direction
+	Radiance(direction primitive.Vec3) primitive.ScalarColor
+	// Picks a direction towards the light, returns it with its radiance & solid angle density
+	Sample(u primitive.Vec2) (primitive.Vec3, primitive.ScalarColor, float32)
+	// Solid angle density of Sample choosing the given direction
+	Pdf(direction primitive.Vec3) float32
+}
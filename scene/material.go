@@ -2,20 +2,110 @@ package scene
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/ruegerj/raytracing/common"
-	"github.com/ruegerj/raytracing/config"
 	"github.com/ruegerj/raytracing/primitive"
 )
 
-const glass_ior float32 = 1.52
+type LobeFlags uint8
 
+const (
+	LOBE_REFLECTION LobeFlags = 1 << iota
+	LOBE_TRANSMISSION
+	LOBE_DIFFUSE
+	LOBE_GLOSSY
+	// Dirac delta lobe, which can only be sampled but never evaluated
+	LOBE_SPECULAR
+)
+
+func (f LobeFlags) IsSpecular() bool {
+	return f&LOBE_SPECULAR != 0
+}
+
+func (f LobeFlags) IsNonSpecular() bool {
+	return f&(LOBE_DIFFUSE|LOBE_GLOSSY) != 0
+}
+
+func (f LobeFlags) IsTransmission() bool {
+	return f&LOBE_TRANSMISSION != 0
+}
+
+type BSDFSample struct {
+	// Incident direction, pointing away from the surface
+	Direction primitive.Vec3
+	F         primitive.ScalarColor
+	// Solid angle density of the direction, for specular lobes the discrete probability of choosing the lobe
+	Pdf   float32
+	Flags LobeFlags
+}
+
+// Bidirectional scattering distribution function of a surface. All directions point away from the surface, where wo
+// leads towards the viewer & wi towards the light. The hit normal always lies on the same side as wo.
 type Material interface {
-	Scatter(ray primitive.Ray, hit *Hit, world *World) (primitive.Ray, bool, primitive.ScalarColor)
+	// Samples an incident direction using the uniform 1D & 2D samples uc & u
+	Sample(wo primitive.Vec3, hit *Hit, uc float32, u primitive.Vec2) (BSDFSample, bool)
+	// Returns the value of the BSDF without the cosine term, always zero for specular lobes
+	Eval(wo, wi primitive.Vec3, hit *Hit) primitive.ScalarColor
+	// Returns the solid angle density of Sample choosing wi, always zero for specular lobes
+	Pdf(wo, wi primitive.Vec3, hit *Hit) float32
+	// Returns the radiance emitted by the surface towards the viewer
+	Emitted(hit *Hit) primitive.ScalarColor
+	// Returns the union of the lobes the material consists of
+	Flags() LobeFlags
+}
+
+// Implemented by materials emitting light, the average radiance allows them to be sampled directly
+type Emitter interface {
+	Radiance() primitive.ScalarColor
+}
+
+// Implemented by materials perturbing the shading normal, e.g. through a normal map. The returned normal is expected
+// in world space, the tangent frame of the hit is only available if the surface has tangents.
+type NormalMapper interface {
+	MapNormal(hit *Hit) primitive.Vec3
+}
+
+// Implemented by materials which can be lit by uniform ambient light, as done for previews. Returns the reflectance
+// towards ambient light, including the ambient occlusion of the material.
+type AmbientShader interface {
+	Ambient(hit *Hit) primitive.ScalarColor
+}
+
+// Implemented by materials enclosing a volume. Where volumes overlap, the one with the highest priority claims the
+// overlapping region, the surfaces of the others are skipped as false interfaces.
+type VolumeBoundary interface {
+	// Returns the medium on the inside of the surface, nil if there is none
+	Interior() *Medium
+	IOR() float32
+	Priority() int
+}
+
+// Implemented by materials which may be single sided, as opposed to the materials assumed to be double sided
+type Sided interface {
+	DoubleSided() bool
+}
+
+// Single sided surfaces are invisible from behind, unless they transmit light & therefore have to be hit from within
+func CullsBackFaces(material Material) bool {
+	if material == nil {
+		return false
+	}
+
+	sided, ok := material.(Sided)
+	return ok && !sided.DoubleSided() && !material.Flags().IsTransmission()
+}
+
+// Implemented by materials which may be partially transparent, e.g. cut-out foliage or decals
+type AlphaTester interface {
+	// Returns false if the material is opaque everywhere, such that the alpha test can be skipped
+	HasAlpha() bool
+	// Decides whether the surface at the texture coordinates is hit, the uniform sample u drives the stochastic
+	// transparency of blended materials
+	AlphaTest(uv *primitive.Vec2, u float32) bool
 }
 
 var _ Material = (*Diffuse)(nil)
+var _ AmbientShader = (*Diffuse)(nil)
 
 type Diffuse struct {
 	color primitive.ScalarColor
@@ -25,14 +115,48 @@ func NewDiffuse(color primitive.ScalarColor) *Diffuse {
 	return &Diffuse{color: color}
 }
 
-func (d *Diffuse) Scatter(ray primitive.Ray, hit *Hit, world *World) (primitive.Ray, bool, primitive.ScalarColor) {
-	rayDir := (hit.Normal.Add(primitive.RandomUnitVector())).Normalize()
-	rayOrigin := hit.Point.Add(rayDir.MulScalar(config.EPSILON))
-	return primitive.NewRay(rayOrigin, rayDir), true, d.color
+func (d *Diffuse) Sample(wo primitive.Vec3, hit *Hit, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	wi := primitive.NewFrame(hit.Normal).FromLocal(primitive.SampleCosineHemisphere(u)).Normalize()
+	cosTheta := hit.Normal.Dot(wi)
+	if cosTheta <= 0.0 {
+		return BSDFSample{}, false
+	}
+
+	return BSDFSample{
+		Direction: wi,
+		F:         d.color.MulScalar(1 / math.Pi),
+		Pdf:       primitive.CosineHemispherePdf(cosTheta),
+		Flags:     d.Flags(),
+	}, true
+}
+
+func (d *Diffuse) Eval(wo, wi primitive.Vec3, hit *Hit) primitive.ScalarColor {
+	if hit.Normal.Dot(wi) <= 0.0 {
+		return primitive.BLACK
+	}
+	return d.color.MulScalar(1 / math.Pi)
+}
+
+func (d *Diffuse) Pdf(wo, wi primitive.Vec3, hit *Hit) float32 {
+	return primitive.CosineHemispherePdf(max(0, hit.Normal.Dot(wi)))
+}
+
+func (d *Diffuse) Ambient(hit *Hit) primitive.ScalarColor {
+	return d.color
+}
+
+func (d *Diffuse) Emitted(hit *Hit) primitive.ScalarColor {
+	return primitive.BLACK
+}
+
+func (d *Diffuse) Flags() LobeFlags {
+	return LOBE_REFLECTION | LOBE_DIFFUSE
 }
 
 var _ Material = (*Metal)(nil)
 
+// Mirror reflection perturbed by a random offset scaled with the roughness. The fuzzed reflection has no closed form
+// density, hence the material is treated like a specular one.
 type Metal struct {
 	color     primitive.ScalarColor
 	roughness float32
@@ -45,46 +169,101 @@ func NewMetal(color primitive.ScalarColor, roughness float32) *Metal {
 	}
 }
 
-func (m *Metal) Scatter(ray primitive.Ray, hit *Hit, world *World) (primitive.Ray, bool, primitive.ScalarColor) {
-	randomReflection := primitive.RandomOnHemisphere(hit.Normal).MulScalar(m.roughness)
-	reflectionDir := ray.Direction().Reflect(hit.Normal).Add(randomReflection).Normalize()
-	reflectionOrigin := hit.Point.Add(reflectionDir.MulScalar(config.EPSILON))
-	return primitive.NewRay(reflectionOrigin, reflectionDir), true, m.color
+func (m *Metal) Sample(wo primitive.Vec3, hit *Hit, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	randomReflection := primitive.NewFrame(hit.Normal).FromLocal(primitive.SampleUniformHemisphere(u)).MulScalar(m.roughness)
+	wi := wo.Negate().Reflect(hit.Normal).Add(randomReflection).Normalize()
+
+	cosTheta := hit.Normal.Dot(wi)
+	if cosTheta <= 0.0 {
+		return BSDFSample{}, false // fuzzed below the surface
+	}
+
+	return BSDFSample{
+		Direction: wi,
+		F:         m.color.MulScalar(1 / cosTheta),
+		Pdf:       1,
+		Flags:     m.Flags(),
+	}, true
+}
+
+func (m *Metal) Eval(wo, wi primitive.Vec3, hit *Hit) primitive.ScalarColor {
+	return primitive.BLACK
+}
+
+func (m *Metal) Pdf(wo, wi primitive.Vec3, hit *Hit) float32 {
+	return 0
+}
+
+func (m *Metal) Emitted(hit *Hit) primitive.ScalarColor {
+	return primitive.BLACK
+}
+
+func (m *Metal) Flags() LobeFlags {
+	return LOBE_REFLECTION | LOBE_SPECULAR
 }
 
 var _ Material = (*Glass)(nil)
 
 type Glass struct {
 	color primitive.ScalarColor
+	ior   float32
 }
 
-func NewGlass(color primitive.ScalarColor) *Glass {
-	return &Glass{color: color}
+func NewGlass(color primitive.ScalarColor, ior float32) *Glass {
+	return &Glass{color: color, ior: ior}
 }
 
-func (g *Glass) Scatter(ray primitive.Ray, hit *Hit, world *World) (primitive.Ray, bool, primitive.ScalarColor) {
-	eta := glass_ior
-	if hit.FrontFace {
+func (g *Glass) Sample(wo primitive.Vec3, hit *Hit, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	eta := g.ior
+	if !hit.FrontFace {
 		eta = common.Recip(eta)
 	}
 
-	cosTheta := min(ray.Direction().Negate().Dot(hit.Normal), 1.0)
-	sinTheta := float32(math.Sqrt(float64(1.0 - cosTheta*cosTheta)))
-	cannotRefract := eta*sinTheta > 1.0
+	cosTheta := wo.Dot(hit.Normal)
+	reflectance := fresnelDielectric(cosTheta, eta)
+
+	if reflectance > uc {
+		wi := wo.Negate().Reflect(hit.Normal)
+		return BSDFSample{
+			Direction: wi,
+			F:         g.color.MulScalar(reflectance / common.Abs(wi.Dot(hit.Normal))),
+			Pdf:       reflectance,
+			Flags:     LOBE_REFLECTION | LOBE_SPECULAR,
+		}, true
+	}
 
-	var targetDir primitive.Vec3
-	doesReflect := cannotRefract || reflectanceSchlick(cosTheta, eta) > rand.Float32()
-	if doesReflect {
-		targetDir = ray.Direction().Reflect(hit.Normal)
-	} else {
-		targetDir = ray.Direction().Refract(hit.Normal, eta)
+	wi, refracted := refract(wo, hit.Normal, eta)
+	if !refracted {
+		return BSDFSample{}, false
 	}
 
-	rayOrigin := hit.Point.Add(targetDir.MulScalar(config.EPSILON))
-	return primitive.NewRay(rayOrigin, targetDir), true, g.color
+	transmittance := 1 - reflectance
+	return BSDFSample{
+		Direction: wi,
+		F:         g.color.MulScalar(transmittance / common.Abs(wi.Dot(hit.Normal))),
+		Pdf:       transmittance,
+		Flags:     LOBE_TRANSMISSION | LOBE_SPECULAR,
+	}, true
+}
+
+func (g *Glass) Eval(wo, wi primitive.Vec3, hit *Hit) primitive.ScalarColor {
+	return primitive.BLACK
+}
+
+func (g *Glass) Pdf(wo, wi primitive.Vec3, hit *Hit) float32 {
+	return 0
+}
+
+func (g *Glass) Emitted(hit *Hit) primitive.ScalarColor {
+	return primitive.BLACK
+}
+
+func (g *Glass) Flags() LobeFlags {
+	return LOBE_REFLECTION | LOBE_TRANSMISSION | LOBE_SPECULAR
 }
 
 var _ Material = (*Emissive)(nil)
+var _ Emitter = (*Emissive)(nil)
 
 type Emissive struct {
 	color primitive.ScalarColor
@@ -94,12 +273,26 @@ func NewEmissive(color primitive.ScalarColor) *Emissive {
 	return &Emissive{color: color}
 }
 
-func (e *Emissive) Scatter(ray primitive.Ray, hit *Hit, world *World) (primitive.Ray, bool, primitive.ScalarColor) {
-	return primitive.Ray{}, false, e.color
+func (e *Emissive) Sample(wo primitive.Vec3, hit *Hit, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	return BSDFSample{}, false
+}
+
+func (e *Emissive) Eval(wo, wi primitive.Vec3, hit *Hit) primitive.ScalarColor {
+	return primitive.BLACK
+}
+
+func (e *Emissive) Pdf(wo, wi primitive.Vec3, hit *Hit) float32 {
+	return 0
+}
+
+func (e *Emissive) Emitted(hit *Hit) primitive.ScalarColor {
+	return e.color
+}
+
+func (e *Emissive) Flags() LobeFlags {
+	return 0
 }
 
-func reflectanceSchlick(cosine, ior float32) float32 {
-	r0 := common.Pow((1.0-ior)/(1.0+ior), 2.0)
-	approx := common.Pow(r0+(1.0-r0)*(1.0-cosine), 5.0)
-	return approx
+func (e *Emissive) Radiance() primitive.ScalarColor {
+	return e.color
 }
@@ -0,0 +1,50 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Attenuation colors are clamped to this lower bound, as black channels would absorb infinitely
+const min_attenuation_color float32 = 1e-6
+
+// Homogeneous medium absorbing light exponentially with the distance travelled through it (Beer-Lambert law)
+type Medium struct {
+	absorption primitive.ScalarColor
+}
+
+// Creates a medium where white light turns into the attenuation color after travelling the attenuation distance,
+// which has to be positive
+func NewMedium(attenuationColor primitive.ScalarColor, attenuationDistance float32) *Medium {
+	absorptionOf := func(channel float32) float32 {
+		channel = min(max(channel, min_attenuation_color), 1)
+		return -float32(math.Log(float64(channel))) / attenuationDistance
+	}
+
+	return &Medium{
+		absorption: primitive.ScalarColor{
+			R: absorptionOf(attenuationColor.R),
+			G: absorptionOf(attenuationColor.G),
+			B: absorptionOf(attenuationColor.B),
+		},
+	}
+}
+
+// Returns the fraction of light remaining after travelling the given distance through the medium, which may be
+// infinite
+func (m *Medium) Transmittance(distance float32) primitive.ScalarColor {
+	transmittanceOf := func(absorption float32) float32 {
+		// channels without absorption stay clear over any distance
+		if absorption == 0 {
+			return 1
+		}
+		return float32(math.Exp(float64(-absorption * distance)))
+	}
+
+	return primitive.ScalarColor{
+		R: transmittanceOf(m.absorption.R),
+		G: transmittanceOf(m.absorption.G),
+		B: transmittanceOf(m.absorption.B),
+	}
+}
@@ -0,0 +1,37 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+func TestMediumTransmittance(t *testing.T) {
+	infinity := float32(math.Inf(1))
+
+	tests := []struct {
+		name     string
+		color    primitive.ScalarColor
+		distance float32
+		want     primitive.ScalarColor
+	}{
+		{"attenuation distance", primitive.ScalarColor{R: 0.5, G: 0.25, B: 1}, 2, primitive.ScalarColor{R: 0.5, G: 0.25, B: 1}},
+		{"twice the distance", primitive.ScalarColor{R: 0.5, G: 0.25, B: 1}, 4, primitive.ScalarColor{R: 0.25, G: 0.0625, B: 1}},
+		{"no distance", primitive.ScalarColor{R: 0.5, G: 0.25, B: 1}, 0, primitive.ScalarColor{R: 1, G: 1, B: 1}},
+		{"infinite distance", primitive.ScalarColor{R: 0.5, G: 1, B: 1}, infinity, primitive.ScalarColor{R: 0, G: 1, B: 1}},
+		// black channels are clamped & colors above one don't amplify
+		{"out of range colors", primitive.ScalarColor{R: 0, G: 2, B: 1}, 2, primitive.ScalarColor{R: 1e-6, G: 1, B: 1}},
+	}
+
+	for _, tt := range tests {
+		got := NewMedium(tt.color, 2).Transmittance(tt.distance)
+		if !approxEqualColor(got, tt.want) {
+			t.Errorf("%s: transmittance = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func approxEqualColor(a, b primitive.ScalarColor) bool {
+	return approxEqualRelative(a.R, b.R) && approxEqualRelative(a.G, b.G) && approxEqualRelative(a.B, b.B)
+}
@@ -0,0 +1,149 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Below this alpha the distribution is numerically indistinguishable from a perfect mirror
+const smooth_alpha_threshold float32 = 1e-3
+
+// Trowbridge-Reitz (GGX) microfacet distribution with Smith masking-shadowing. All directions are expected in the
+// local shading frame, where +Z is the macro surface normal.
+type trowbridgeReitz struct {
+	alphaX, alphaY float32
+}
+
+// Maps the perceptual roughness of glTF to the alpha of the distribution
+func newTrowbridgeReitz(roughness float32) trowbridgeReitz {
+	alpha := roughness * roughness
+	return trowbridgeReitz{alphaX: alpha, alphaY: alpha}
+}
+
+// Stretches the distribution along the X axis of the shading frame, as specified by KHR_materials_anisotropy
+func newAnisotropicTrowbridgeReitz(isotropic trowbridgeReitz, anisotropy float32) trowbridgeReitz {
+	alphaX := isotropic.alphaX + (1-isotropic.alphaX)*anisotropy*anisotropy
+	// a vanishing alpha along one axis only would render the distribution degenerate
+	alphaY := max(isotropic.alphaY, smooth_alpha_threshold)
+	return trowbridgeReitz{alphaX: alphaX, alphaY: alphaY}
+}
+
+func (tr trowbridgeReitz) effectivelySmooth() bool {
+	return max(tr.alphaX, tr.alphaY) < smooth_alpha_threshold
+}
+
+// Returns the differential area of microfacets oriented along wm
+func (tr trowbridgeReitz) D(wm primitive.Vec3) float32 {
+	cos2Theta := wm.Z * wm.Z
+	if cos2Theta == 0 {
+		return 0
+	}
+
+	e := (wm.X*wm.X/(tr.alphaX*tr.alphaX) + wm.Y*wm.Y/(tr.alphaY*tr.alphaY)) / cos2Theta
+	return 1 / (math.Pi * tr.alphaX * tr.alphaY * cos2Theta * cos2Theta * (1 + e) * (1 + e))
+}
+
+func (tr trowbridgeReitz) lambda(w primitive.Vec3) float32 {
+	cos2Theta := w.Z * w.Z
+	if cos2Theta == 0 {
+		return 0
+	}
+
+	alpha2Tan2Theta := (w.X*w.X*tr.alphaX*tr.alphaX + w.Y*w.Y*tr.alphaY*tr.alphaY) / cos2Theta
+	return (float32(math.Sqrt(float64(1+alpha2Tan2Theta))) - 1) / 2
+}
+
+// Returns the fraction of microfacets visible from w
+func (tr trowbridgeReitz) G1(w primitive.Vec3) float32 {
+	return 1 / (1 + tr.lambda(w))
+}
+
+// Returns the fraction of microfacets visible from both directions (height-correlated)
+func (tr trowbridgeReitz) G(wo, wi primitive.Vec3) float32 {
+	return 1 / (1 + tr.lambda(wo) + tr.lambda(wi))
+}
+
+// Returns the density of microfacet normals visible from w
+func (tr trowbridgeReitz) visibleD(w, wm primitive.Vec3) float32 {
+	if w.Z == 0 {
+		return 0
+	}
+	return tr.G1(w) / common.Abs(w.Z) * tr.D(wm) * common.Abs(w.Dot(wm))
+}
+
+func (tr trowbridgeReitz) Pdf(w, wm primitive.Vec3) float32 {
+	return tr.visibleD(w, wm)
+}
+
+// Samples a microfacet normal from the distribution of normals visible from w (Heitz, "Sampling the GGX Distribution
+// of Visible Normals")
+func (tr trowbridgeReitz) SampleWm(w primitive.Vec3, u primitive.Vec2) primitive.Vec3 {
+	// transform w to the hemispherical configuration
+	wh := primitive.Vec3{X: tr.alphaX * w.X, Y: tr.alphaY * w.Y, Z: w.Z}.Normalize()
+	if wh.Z < 0 {
+		wh = wh.Negate()
+	}
+
+	t1 := primitive.Vec3{X: 1}
+	if wh.Z < 0.99999 {
+		t1 = primitive.Vec3{Z: 1}.Cross(wh).Normalize()
+	}
+	t2 := wh.Cross(t1)
+
+	// warp a disk sample onto the projected area of the visible hemisphere
+	p := primitive.SampleUniformDiskConcentric(u)
+	h := float32(math.Sqrt(float64(1 - p.X*p.X)))
+	s := (1 + wh.Z) / 2
+	p.Y = (1-s)*h + s*p.Y
+
+	pz := float32(math.Sqrt(float64(max(0, 1-p.X*p.X-p.Y*p.Y))))
+	nh := t1.MulScalar(p.X).Add(t2.MulScalar(p.Y)).Add(wh.MulScalar(pz))
+
+	// transform back to the ellipsoid configuration
+	return primitive.Vec3{X: tr.alphaX * nh.X, Y: tr.alphaY * nh.Y, Z: max(1e-6, nh.Z)}.Normalize()
+}
+
+func schlickFresnel(f0 primitive.ScalarColor, cosTheta float32) primitive.ScalarColor {
+	weight := schlickWeight(cosTheta)
+	return f0.MulScalar(1 - weight).AddScalar(weight)
+}
+
+func schlickWeight(cosTheta float32) float32 {
+	m := min(max(1-cosTheta, 0), 1)
+	return m * m * m * m * m
+}
+
+// Unpolarized fresnel reflectance of a dielectric interface, where eta is the ratio of the IOR on the transmitted side
+// to the one on the incident side. A negative cosine indicates an incident direction on the transmitted side.
+func fresnelDielectric(cosThetaI, eta float32) float32 {
+	cosThetaI = min(max(cosThetaI, -1), 1)
+	if cosThetaI < 0 {
+		eta = common.Recip(eta)
+		cosThetaI = -cosThetaI
+	}
+
+	sin2ThetaT := (1 - cosThetaI*cosThetaI) / (eta * eta)
+	if sin2ThetaT >= 1 {
+		return 1 // total internal reflection
+	}
+	cosThetaT := float32(math.Sqrt(float64(1 - sin2ThetaT)))
+
+	parallel := (eta*cosThetaI - cosThetaT) / (eta*cosThetaI + cosThetaT)
+	perpendicular := (cosThetaI - eta*cosThetaT) / (cosThetaI + eta*cosThetaT)
+	return (parallel*parallel + perpendicular*perpendicular) / 2
+}
+
+// Refracts w (pointing away from the surface) through an interface with the normal n on the same side, returns false
+// on total internal reflection
+func refract(w, n primitive.Vec3, eta float32) (primitive.Vec3, bool) {
+	cosThetaI := n.Dot(w)
+	sin2ThetaT := max(0, 1-cosThetaI*cosThetaI) / (eta * eta)
+	if sin2ThetaT >= 1 {
+		return primitive.Vec3{}, false
+	}
+
+	cosThetaT := float32(math.Sqrt(float64(1 - sin2ThetaT)))
+	return w.Negate().DivScalar(eta).Add(n.MulScalar(cosThetaI/eta - cosThetaT)), true
+}
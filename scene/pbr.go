@@ -0,0 +1,810 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// IOR assumed by the glTF metallic-roughness model, unless specified otherwise
+const default_ior float32 = 1.5
+
+var _ Material = (*PBR)(nil)
+var _ NormalMapper = (*PBR)(nil)
+var _ AmbientShader = (*PBR)(nil)
+var _ Emitter = (*PBR)(nil)
+var _ VolumeBoundary = (*PBR)(nil)
+var _ AlphaTester = (*PBR)(nil)
+var _ Sided = (*PBR)(nil)
+
+// Interpretation of the alpha of the base color, as defined by glTF
+type AlphaMode uint8
+
+const (
+	ALPHA_OPAQUE AlphaMode = iota
+	// Surface is either fully opaque or fully transparent, depending on whether the alpha reaches the cutoff
+	ALPHA_MASK
+	// Surface is hit with a probability equal to the alpha
+	ALPHA_BLEND
+)
+
+// BSDF of the glTF metallic-roughness model: a GGX specular layer on top of a base for dielectrics & a tinted GGX
+// specular for conductors, blended by the metallic factor. The dielectric base blends between lambertian reflection
+// & GGX transmission by the transmission factor.
+type PBR struct {
+	baseColor        primitive.ScalarColor
+	baseColorTexture *Texture
+	alpha            float32
+	alphaMode        AlphaMode
+	alphaCutoff      float32
+	doubleSided      bool
+	normalTexture    *Texture
+	normalScale      float32
+	metallic         float32
+	roughness        float32
+	// Metallic is read from the blue & roughness from the green channel
+	metallicRoughnessTexture *Texture
+	occlusionTexture         *Texture
+	occlusionStrength        float32
+	emission                 primitive.ScalarColor
+	emissionTexture          *Texture
+	// Emission averaged over the texture, which is used to weight the material for emitter sampling
+	averageEmission     primitive.ScalarColor
+	ior                 float32
+	transmission        float32
+	transmissionTexture *Texture
+	interior            *Medium
+	priority            int
+	// Clear coat factor is read from the red, its roughness from the green channel of the textures
+	clearcoat                 float32
+	clearcoatTexture          *Texture
+	clearcoatRoughness        float32
+	clearcoatRoughnessTexture *Texture
+	clearcoatNormalTexture    *Texture
+	clearcoatNormalScale      float32
+	// Sheen color is read from the RGB, its roughness from the alpha channel of the textures
+	sheenColor            primitive.ScalarColor
+	sheenColorTexture     *Texture
+	sheenRoughness        float32
+	sheenRoughnessTexture *Texture
+	// Specular strength is read from the alpha channel, its color from the RGB channels of the textures
+	specular             float32
+	specularTexture      *Texture
+	specularColor        primitive.ScalarColor
+	specularColorTexture *Texture
+	// Anisotropy direction in tangent space is read from the red & green, its strength from the blue channel
+	anisotropy         float32
+	anisotropyRotation float32
+	anisotropyTexture  *Texture
+	// Iridescence factor is read from the red, the film thickness from the green channel of the textures
+	iridescence                 float32
+	iridescenceTexture          *Texture
+	iridescenceIOR              float32
+	iridescenceThicknessMin     float32
+	iridescenceThicknessMax     float32
+	iridescenceThicknessTexture *Texture
+}
+
+func NewPBR(baseColor primitive.ScalarColor, metallic, roughness float32) *PBR {
+	return &PBR{
+		baseColor:     baseColor,
+		alpha:         1,
+		metallic:      metallic,
+		roughness:     roughness,
+		ior:           default_ior,
+		specular:      1,
+		specularColor: primitive.ScalarColor{R: 1, G: 1, B: 1},
+	}
+}
+
+func (p *PBR) SetIOR(ior float32) {
+	p.ior = ior
+}
+
+// Sets the fraction of light transmitted through the dielectric base, optionally scaled by the red channel of the
+// given texture. Without a volume the surface is treated as the boundary of a solid, refracting object.
+func (p *PBR) SetTransmission(transmission float32, texture *Texture) {
+	p.transmission = transmission
+	p.transmissionTexture = texture
+}
+
+// Modulates the base color factor with the given texture
+func (p *PBR) SetBaseColorTexture(texture *Texture) {
+	p.baseColorTexture = texture
+}
+
+// The alpha is multiplied with the alpha channel of the base color texture, the cutoff only applies to ALPHA_MASK
+func (p *PBR) SetAlpha(alpha float32, mode AlphaMode, cutoff float32) {
+	p.alpha = alpha
+	p.alphaMode = mode
+	p.alphaCutoff = cutoff
+}
+
+func (p *PBR) SetDoubleSided(doubleSided bool) {
+	p.doubleSided = doubleSided
+}
+
+// Perturbs the shading normal using the given tangent space normal map, the scale is applied to its X & Y components
+func (p *PBR) SetNormalTexture(texture *Texture, scale float32) {
+	p.normalTexture = texture
+	p.normalScale = scale
+}
+
+// Scales the metallic & roughness factors by the given texture
+func (p *PBR) SetMetallicRoughnessTexture(texture *Texture) {
+	p.metallicRoughnessTexture = texture
+}
+
+// Attenuates ambient lighting by the red channel of the given texture, scaled by the strength
+func (p *PBR) SetOcclusionTexture(texture *Texture, strength float32) {
+	p.occlusionTexture = texture
+	p.occlusionStrength = strength
+}
+
+// Lets the surface emit the given unbounded radiance on top of its reflection, optionally scaled by a texture
+func (p *PBR) SetEmission(emission primitive.ScalarColor, texture *Texture) {
+	p.emission = emission
+	p.emissionTexture = texture
+
+	p.averageEmission = emission
+	if texture != nil {
+		p.averageEmission = emission.Mul(texture.Average())
+	}
+}
+
+// Fills the enclosed volume with the given medium
+func (p *PBR) SetInterior(medium *Medium) {
+	p.interior = medium
+}
+
+// Adds a dielectric coating on top of the material
+func (p *PBR) SetClearcoat(factor float32, texture *Texture, roughness float32, roughnessTexture *Texture) {
+	p.clearcoat = factor
+	p.clearcoatTexture = texture
+	p.clearcoatRoughness = roughness
+	p.clearcoatRoughnessTexture = roughnessTexture
+}
+
+// Perturbs the normal of the coating independent of the base, which otherwise uses the unperturbed vertex normal
+func (p *PBR) SetClearcoatNormalTexture(texture *Texture, scale float32) {
+	p.clearcoatNormalTexture = texture
+	p.clearcoatNormalScale = scale
+}
+
+// Adds a layer of fabric like sheen on top of the base, which is disabled for a black color
+func (p *PBR) SetSheen(color primitive.ScalarColor, colorTexture *Texture, roughness float32, roughnessTexture *Texture) {
+	p.sheenColor = color
+	p.sheenColorTexture = colorTexture
+	p.sheenRoughness = roughness
+	p.sheenRoughnessTexture = roughnessTexture
+}
+
+// Scales the reflectance of the dielectric, whose reflectance at normal incidence is additionally tinted by the color
+func (p *PBR) SetSpecular(factor float32, texture *Texture, color primitive.ScalarColor, colorTexture *Texture) {
+	p.specular = factor
+	p.specularTexture = texture
+	p.specularColor = color
+	p.specularColorTexture = colorTexture
+}
+
+// Stretches the specular highlights along the tangent rotated by the given angle (radians), requires tangents
+func (p *PBR) SetAnisotropy(strength, rotation float32, texture *Texture) {
+	p.anisotropy = strength
+	p.anisotropyRotation = rotation
+	p.anisotropyTexture = texture
+}
+
+// Adds a thin film on top of the material, the thickness texture interpolates between the min & max (nanometers)
+func (p *PBR) SetIridescence(factor float32, texture *Texture, ior, thicknessMin, thicknessMax float32, thicknessTexture *Texture) {
+	p.iridescence = factor
+	p.iridescenceTexture = texture
+	p.iridescenceIOR = ior
+	p.iridescenceThicknessMin = thicknessMin
+	p.iridescenceThicknessMax = thicknessMax
+	p.iridescenceThicknessTexture = thicknessTexture
+}
+
+// Sets the priority of the enclosed volume over overlapping ones, higher values take precedence
+func (p *PBR) SetPriority(priority int) {
+	p.priority = priority
+}
+
+func (p *PBR) MapNormal(hit *Hit) primitive.Vec3 {
+	return hit.perturbedNormal(p.normalTexture, p.normalScale)
+}
+
+func (p *PBR) Sample(wo primitive.Vec3, hit *Hit, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	return p.layersAt(hit).sample(wo, uc, u)
+}
+
+func (p *PBR) Eval(wo, wi primitive.Vec3, hit *Hit) primitive.ScalarColor {
+	return p.layersAt(hit).eval(wo, wi)
+}
+
+func (p *PBR) Pdf(wo, wi primitive.Vec3, hit *Hit) float32 {
+	return p.layersAt(hit).pdf(wo, wi)
+}
+
+func (p *PBR) DoubleSided() bool {
+	return p.doubleSided
+}
+
+func (p *PBR) HasAlpha() bool {
+	return p.alphaMode != ALPHA_OPAQUE
+}
+
+func (p *PBR) AlphaTest(uv *primitive.Vec2, u float32) bool {
+	alpha := p.alpha
+	if p.baseColorTexture != nil && uv != nil {
+		alpha *= p.baseColorTexture.SampleAlpha(*uv)
+	}
+
+	switch p.alphaMode {
+	case ALPHA_MASK:
+		return alpha >= p.alphaCutoff
+	case ALPHA_BLEND:
+		return u < alpha
+	default:
+		return true
+	}
+}
+
+func (p *PBR) Ambient(hit *Hit) primitive.ScalarColor {
+	albedo := p.surfaceAt(hit).baseColor
+	if p.occlusionTexture == nil || hit.UV == nil {
+		return albedo
+	}
+
+	occlusion := p.occlusionTexture.Sample(*hit.UV).R
+	return albedo.MulScalar(1 + p.occlusionStrength*(occlusion-1))
+}
+
+func (p *PBR) Emitted(hit *Hit) primitive.ScalarColor {
+	if p.emissionTexture == nil || hit.UV == nil {
+		return p.emission
+	}
+	return p.emission.Mul(p.emissionTexture.Sample(*hit.UV))
+}
+
+func (p *PBR) Radiance() primitive.ScalarColor {
+	return p.averageEmission
+}
+
+func (p *PBR) Interior() *Medium {
+	return p.interior
+}
+
+func (p *PBR) IOR() float32 {
+	return p.ior
+}
+
+func (p *PBR) Priority() int {
+	return p.priority
+}
+
+func (p *PBR) Flags() LobeFlags {
+	glossiness := LOBE_GLOSSY
+	if newTrowbridgeReitz(p.roughness).effectivelySmooth() {
+		glossiness = LOBE_SPECULAR
+	}
+
+	flags := LOBE_REFLECTION | glossiness
+	if (p.metallic < 1 || p.metallicRoughnessTexture != nil) && p.transmission < 1 {
+		flags |= LOBE_DIFFUSE
+	}
+	if p.transmission > 0 {
+		flags |= LOBE_TRANSMISSION | glossiness
+	}
+	if p.sheenColor.MaxComponent() > 0 {
+		flags |= LOBE_GLOSSY
+	}
+	if p.clearcoat > 0 {
+		if newTrowbridgeReitz(p.clearcoatRoughness).effectivelySmooth() {
+			flags |= LOBE_SPECULAR
+		} else {
+			flags |= LOBE_GLOSSY
+		}
+	}
+	return flags
+}
+
+// Parameters of the material resolved at a specific hit, all directions are expected in the local shading frame
+type pbrSurface struct {
+	baseColor    primitive.ScalarColor
+	metallic     float32
+	transmission float32
+	// Ratio of the IOR on the opposite side of the surface to the one on the side of wo
+	eta           float32
+	specular      float32
+	specularColor primitive.ScalarColor
+	film          thinFilm
+	distribution  trowbridgeReitz
+}
+
+// Layers of the material resolved at a specific hit, from top to bottom: clear coat, sheen & the base surface.
+// Each layer only passes on the energy it doesn't reflect itself, as estimated from the viewing direction.
+type pbrLayers struct {
+	// Shading frame of the sheen & the base, the clear coat has its own normal
+	frame     primitive.Frame
+	clearcoat clearcoatLayer
+	sheen     sheenLayer
+	base      pbrSurface
+}
+
+func (p *PBR) layersAt(hit *Hit) pbrLayers {
+	frame, anisotropy := p.anisotropyAt(hit)
+	base := p.surfaceAt(hit)
+	if anisotropy > 0 {
+		base.distribution = newAnisotropicTrowbridgeReitz(base.distribution, anisotropy)
+	}
+
+	return pbrLayers{
+		frame:     frame,
+		clearcoat: p.clearcoatAt(hit),
+		sheen:     p.sheenAt(hit),
+		base:      base,
+	}
+}
+
+// Builds the shading frame with its X axis along the direction of the anisotropy & returns its strength. Surfaces
+// without tangents are isotropic, as the direction is defined in tangent space.
+func (p *PBR) anisotropyAt(hit *Hit) (primitive.Frame, float32) {
+	if p.anisotropy <= 0 || hit.Tangent.LengthSquared() == 0 {
+		return primitive.NewFrame(hit.Normal), 0
+	}
+
+	direction := primitive.Vec2{X: 1, Y: 0}
+	strength := p.anisotropy
+	if p.anisotropyTexture != nil && hit.UV != nil {
+		texel := p.anisotropyTexture.Sample(*hit.UV)
+		direction = primitive.Vec2{X: 2*texel.R - 1, Y: 2*texel.G - 1}
+		strength *= texel.B
+	}
+
+	sin, cos := math.Sincos(float64(p.anisotropyRotation))
+	rotated := primitive.Vec2{
+		X: float32(cos)*direction.X - float32(sin)*direction.Y,
+		Y: float32(sin)*direction.X + float32(cos)*direction.Y,
+	}
+
+	// the tangent frame is orthogonal to the vertex normal, which may differ from the mapped normal
+	tangent := hit.Tangent.MulScalar(rotated.X).Add(hit.Bitangent.MulScalar(rotated.Y))
+	tangent = tangent.Sub(hit.Normal.MulScalar(hit.Normal.Dot(tangent)))
+	if tangent.LengthSquared() == 0 || strength <= 0 {
+		return primitive.NewFrame(hit.Normal), 0
+	}
+
+	tangent = tangent.Normalize()
+	return primitive.Frame{X: tangent, Y: hit.Normal.Cross(tangent), Z: hit.Normal}, strength
+}
+
+// Picks a layer proportional to its weight, before sampling a direction from it. Specular samples are returned
+// right away, all other samples are evaluated on the whole stack of layers.
+func (l pbrLayers) sample(wo primitive.Vec3, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	coatWeight := l.clearcoat.weight(wo)
+	if uc < coatWeight {
+		sample, sampled := l.clearcoat.sample(wo, u)
+		if sampled && sample.Flags.IsSpecular() {
+			sample.Pdf = coatWeight
+			return sample, true
+		}
+		return l.evaluated(sample, sampled, wo)
+	}
+	uc = (uc - coatWeight) / (1 - coatWeight)
+
+	woLocal := l.frame.ToLocal(wo)
+	sheenWeight := l.sheen.weight(woLocal)
+	if uc < sheenWeight {
+		sample, sampled := l.sheen.sample(l.frame, wo, u)
+		return l.evaluated(sample, sampled, wo)
+	}
+	uc = (uc - sheenWeight) / (1 - sheenWeight)
+
+	sample, sampled := l.base.sample(l.frame, wo, uc, u)
+	if sampled && sample.Flags.IsSpecular() {
+		// the layers above take their share of the energy from the base, while the density only depends on the
+		// probability of having picked the base
+		transmitted := (1 - coatWeight) * l.sheen.baseScaling(woLocal, l.frame.ToLocal(sample.Direction))
+		sample.F = sample.F.MulScalar(transmitted)
+		sample.Pdf *= (1 - coatWeight) * (1 - sheenWeight)
+		return sample, true
+	}
+	return l.evaluated(sample, sampled, wo)
+}
+
+func (l pbrLayers) evaluated(sample BSDFSample, sampled bool, wo primitive.Vec3) (BSDFSample, bool) {
+	if !sampled {
+		return BSDFSample{}, false
+	}
+
+	sample.F = l.eval(wo, sample.Direction)
+	sample.Pdf = l.pdf(wo, sample.Direction)
+	return sample, true
+}
+
+func (l pbrLayers) eval(wo, wi primitive.Vec3) primitive.ScalarColor {
+	woLocal := l.frame.ToLocal(wo)
+	wiLocal := l.frame.ToLocal(wi)
+
+	value := l.base.eval(woLocal, wiLocal)
+	if scaling := l.sheen.baseScaling(woLocal, wiLocal); scaling < 1 {
+		value = value.MulScalar(scaling).Add(l.sheen.eval(woLocal, wiLocal))
+	}
+	if coatWeight := l.clearcoat.weight(wo); coatWeight > 0 {
+		value = value.MulScalar(1 - coatWeight).Add(l.clearcoat.eval(wo, wi))
+	}
+	return value
+}
+
+// Mixes the densities of the layers according to the probability of sampling either of them
+func (l pbrLayers) pdf(wo, wi primitive.Vec3) float32 {
+	woLocal := l.frame.ToLocal(wo)
+	wiLocal := l.frame.ToLocal(wi)
+
+	pdf := l.base.pdf(woLocal, wiLocal)
+	if sheenWeight := l.sheen.weight(woLocal); sheenWeight > 0 {
+		pdf = pdf*(1-sheenWeight) + l.sheen.pdf(woLocal, wiLocal)*sheenWeight
+	}
+	if coatWeight := l.clearcoat.weight(wo); coatWeight > 0 {
+		pdf = pdf*(1-coatWeight) + l.clearcoat.pdf(wo, wi)*coatWeight
+	}
+	return pdf
+}
+
+func (p *PBR) surfaceAt(hit *Hit) pbrSurface {
+	baseColor := p.baseColor
+	if p.baseColorTexture != nil && hit.UV != nil {
+		baseColor = baseColor.Mul(p.baseColorTexture.Sample(*hit.UV))
+	}
+
+	metallic := p.metallic
+	roughness := p.roughness
+	if p.metallicRoughnessTexture != nil && hit.UV != nil {
+		texel := p.metallicRoughnessTexture.Sample(*hit.UV)
+		metallic *= texel.B
+		roughness *= texel.G
+	}
+
+	transmission := p.transmission
+	if p.transmissionTexture != nil && hit.UV != nil {
+		transmission *= p.transmissionTexture.Sample(*hit.UV).R
+	}
+
+	exteriorIOR := hit.ExteriorIOR
+	if exteriorIOR <= 0 {
+		exteriorIOR = 1
+	}
+
+	eta := p.ior / exteriorIOR
+	if !hit.FrontFace {
+		eta = common.Recip(eta)
+	}
+
+	specular := p.specular
+	specularColor := p.specularColor
+	if hit.UV != nil {
+		if p.specularTexture != nil {
+			specular *= p.specularTexture.SampleAlpha(*hit.UV)
+		}
+		if p.specularColorTexture != nil {
+			specularColor = specularColor.Mul(p.specularColorTexture.Sample(*hit.UV))
+		}
+	}
+
+	return pbrSurface{
+		baseColor:     baseColor,
+		metallic:      metallic,
+		transmission:  transmission,
+		eta:           eta,
+		specular:      specular,
+		specularColor: specularColor,
+		film:          p.filmAt(hit),
+		distribution:  newTrowbridgeReitz(roughness),
+	}
+}
+
+// The film is only applied on the outside of the surface, where it is surrounded by air
+func (p *PBR) filmAt(hit *Hit) thinFilm {
+	if p.iridescence <= 0 || !hit.FrontFace {
+		return thinFilm{}
+	}
+
+	factor := p.iridescence
+	thickness := p.iridescenceThicknessMax
+	if hit.UV != nil {
+		if p.iridescenceTexture != nil {
+			factor *= p.iridescenceTexture.Sample(*hit.UV).R
+		}
+		if p.iridescenceThicknessTexture != nil {
+			t := p.iridescenceThicknessTexture.Sample(*hit.UV).G
+			thickness = p.iridescenceThicknessMin + (p.iridescenceThicknessMax-p.iridescenceThicknessMin)*t
+		}
+	}
+
+	return thinFilm{
+		factor:    factor,
+		ior:       p.iridescenceIOR,
+		thickness: thickness,
+	}
+}
+
+func (p *PBR) clearcoatAt(hit *Hit) clearcoatLayer {
+	if p.clearcoat <= 0 {
+		return clearcoatLayer{}
+	}
+
+	factor := p.clearcoat
+	if p.clearcoatTexture != nil && hit.UV != nil {
+		factor *= p.clearcoatTexture.Sample(*hit.UV).R
+	}
+
+	roughness := p.clearcoatRoughness
+	if p.clearcoatRoughnessTexture != nil && hit.UV != nil {
+		roughness *= p.clearcoatRoughnessTexture.Sample(*hit.UV).G
+	}
+
+	return clearcoatLayer{
+		factor:       factor,
+		frame:        primitive.NewFrame(hit.perturbedNormal(p.clearcoatNormalTexture, p.clearcoatNormalScale)),
+		distribution: newTrowbridgeReitz(roughness),
+	}
+}
+
+func (p *PBR) sheenAt(hit *Hit) sheenLayer {
+	if p.sheenColor.MaxComponent() <= 0 {
+		return sheenLayer{}
+	}
+
+	color := p.sheenColor
+	roughness := p.sheenRoughness
+	if hit.UV != nil {
+		if p.sheenColorTexture != nil {
+			color = color.Mul(p.sheenColorTexture.Sample(*hit.UV))
+		}
+		if p.sheenRoughnessTexture != nil {
+			roughness *= p.sheenRoughnessTexture.SampleAlpha(*hit.UV)
+		}
+	}
+
+	return newSheenLayer(color, roughness)
+}
+
+// Samples one of the lobes, only specular samples carry their value & density as these can't be evaluated
+func (s pbrSurface) sample(frame primitive.Frame, wo primitive.Vec3, uc float32, u primitive.Vec2) (BSDFSample, bool) {
+	woLocal := frame.ToLocal(wo)
+	if woLocal.Z <= 0 {
+		return BSDFSample{}, false
+	}
+
+	reflectionProbability, diffuseProbability, _ := s.lobeProbabilities(woLocal)
+	smooth := s.distribution.effectivelySmooth()
+
+	var wiLocal primitive.Vec3
+	var flags LobeFlags
+
+	switch {
+	case uc < reflectionProbability:
+		if smooth {
+			wiLocal = primitive.Vec3{X: -woLocal.X, Y: -woLocal.Y, Z: woLocal.Z}
+			return BSDFSample{
+				Direction: frame.FromLocal(wiLocal),
+				F:         s.fresnel(woLocal.Z, s.dielectricFresnel(woLocal.Z)).MulScalar(1 / wiLocal.Z),
+				Pdf:       reflectionProbability,
+				Flags:     LOBE_REFLECTION | LOBE_SPECULAR,
+			}, true
+		}
+
+		wm := s.distribution.SampleWm(woLocal, u)
+		wiLocal = woLocal.Negate().Reflect(wm)
+		if wiLocal.Z <= 0 {
+			return BSDFSample{}, false
+		}
+		flags = LOBE_REFLECTION | LOBE_GLOSSY
+	case uc < reflectionProbability+diffuseProbability:
+		wiLocal = primitive.SampleCosineHemisphere(u)
+		if wiLocal.Z <= 0 {
+			return BSDFSample{}, false
+		}
+		flags = LOBE_REFLECTION | LOBE_DIFFUSE
+	default:
+		if smooth {
+			wi, refracted := refract(woLocal, primitive.Vec3{Z: 1}, s.eta)
+			if !refracted {
+				return BSDFSample{}, false
+			}
+
+			transmissionProbability := 1 - reflectionProbability - diffuseProbability
+			transmittance := (1 - s.dielectricFresnel(woLocal.Z).MaxComponent()) * s.dielectricTransmission()
+			return BSDFSample{
+				Direction: frame.FromLocal(wi),
+				F:         s.baseColor.MulScalar(transmittance / (common.Abs(wi.Z) * s.eta * s.eta)),
+				Pdf:       transmissionProbability,
+				Flags:     LOBE_TRANSMISSION | LOBE_SPECULAR,
+			}, true
+		}
+
+		wm := s.distribution.SampleWm(woLocal, u)
+		wi, refracted := refract(woLocal, wm, s.eta)
+		if !refracted || wi.Z >= 0 {
+			return BSDFSample{}, false
+		}
+		wiLocal = wi
+		flags = LOBE_TRANSMISSION | LOBE_GLOSSY
+	}
+
+	return BSDFSample{
+		Direction: frame.FromLocal(wiLocal),
+		Flags:     flags,
+	}, true
+}
+
+func (s pbrSurface) eval(wo, wi primitive.Vec3) primitive.ScalarColor {
+	if wo.Z <= 0 || wi.Z == 0 {
+		return primitive.BLACK
+	}
+
+	if wi.Z < 0 {
+		return s.evalTransmission(wo, wi)
+	}
+
+	// the base only receives what isn't reflected towards wo, weighting it by the fresnel of the microfacet normal
+	// instead would let it keep most of its energy at grazing angles on top of the specular reflection
+	diffuse := s.diffuse(s.dielectricFresnel(wo.Z).MaxComponent())
+	if s.distribution.effectivelySmooth() {
+		return diffuse
+	}
+
+	wm := wo.Add(wi)
+	if wm.LengthSquared() == 0 {
+		return primitive.BLACK
+	}
+	wm = wm.Normalize()
+
+	cosThetaM := wo.Dot(wm)
+	specular := s.fresnel(cosThetaM, s.dielectricFresnel(cosThetaM)).
+		MulScalar(s.distribution.D(wm) * s.distribution.G(wo, wi) / (4 * wo.Z * wi.Z))
+
+	return diffuse.Add(specular)
+}
+
+// Rough dielectric transmission (Walter et al., "Microfacet Models for Refraction through Rough Surfaces"), tinted
+// by the base color
+func (s pbrSurface) evalTransmission(wo, wi primitive.Vec3) primitive.ScalarColor {
+	if s.transmission <= 0 || s.distribution.effectivelySmooth() {
+		return primitive.BLACK
+	}
+
+	wm, ok := s.refractionHalfVector(wo, wi)
+	if !ok {
+		return primitive.BLACK
+	}
+
+	cosThetaOM := wo.Dot(wm)
+	cosThetaIM := wi.Dot(wm)
+	denom := cosThetaIM + cosThetaOM/s.eta
+	transmittance := (1 - s.dielectricFresnel(cosThetaOM).MaxComponent()) * s.dielectricTransmission()
+
+	// radiance is compressed into the smaller solid angle when entering the denser medium, hence the division by eta²
+	btdf := s.distribution.D(wm) * s.distribution.G(wo, wi) *
+		common.Abs(cosThetaIM*cosThetaOM/(wi.Z*wo.Z*denom*denom)) / (s.eta * s.eta)
+
+	return s.baseColor.MulScalar(transmittance * btdf)
+}
+
+func (s pbrSurface) pdf(wo, wi primitive.Vec3) float32 {
+	if wo.Z <= 0 || wi.Z == 0 {
+		return 0
+	}
+
+	reflectionProbability, diffuseProbability, transmissionProbability := s.lobeProbabilities(wo)
+
+	if wi.Z < 0 {
+		if s.distribution.effectivelySmooth() {
+			return 0
+		}
+
+		wm, ok := s.refractionHalfVector(wo, wi)
+		if !ok {
+			return 0
+		}
+
+		// jacobian of the refraction mapping from microfacet normals to incident directions
+		denom := wi.Dot(wm) + wo.Dot(wm)/s.eta
+		dwmDwi := common.Abs(wi.Dot(wm)) / (denom * denom)
+		return transmissionProbability * s.distribution.Pdf(wo, wm) * dwmDwi
+	}
+
+	pdf := diffuseProbability * primitive.CosineHemispherePdf(wi.Z)
+	if s.distribution.effectivelySmooth() {
+		return pdf
+	}
+
+	wm := wo.Add(wi)
+	if wm.LengthSquared() == 0 {
+		return 0
+	}
+	wm = wm.Normalize()
+
+	// the density of reflected directions is the visible normal density scaled by the jacobian of the reflection
+	reflectionPdf := s.distribution.Pdf(wo, wm) / (4 * wo.Dot(wm))
+	return pdf + reflectionProbability*reflectionPdf
+}
+
+// Returns the microfacet normal refracting wo into wi, false if the configuration isn't physically possible
+func (s pbrSurface) refractionHalfVector(wo, wi primitive.Vec3) (primitive.Vec3, bool) {
+	wm := wi.MulScalar(s.eta).Add(wo)
+	if wm.LengthSquared() == 0 {
+		return primitive.Vec3{}, false
+	}
+	wm = wm.Normalize()
+	if wm.Z < 0 {
+		wm = wm.Negate()
+	}
+
+	// discard back facing microfacets
+	if wm.Dot(wi) >= 0 || wm.Dot(wo) <= 0 {
+		return primitive.Vec3{}, false
+	}
+	return wm, true
+}
+
+// Lambertian base of the dielectric, only receiving the energy which is neither reflected nor transmitted
+func (s pbrSurface) diffuse(dielectricReflectance float32) primitive.ScalarColor {
+	weight := (1 - s.metallic) * (1 - s.transmission) * (1 - dielectricReflectance)
+	return s.baseColor.MulScalar(weight / math.Pi)
+}
+
+// Specular reflectance, blended between the given dielectric reflectance & the base color tinted conductor
+func (s pbrSurface) fresnel(cosTheta float32, dielectric primitive.ScalarColor) primitive.ScalarColor {
+	conductor := schlickFresnel(s.baseColor, cosTheta)
+	if s.film.factor > 0 {
+		conductor = lerpColor(s.film.factor, conductor, s.film.fresnel(cosTheta, 1, s.baseColor))
+	}
+	return dielectric.MulScalar(1 - s.metallic).Add(conductor.MulScalar(s.metallic))
+}
+
+// Reflectance of the dielectric, the specular color only tints it at normal incidence while the exact fresnel
+// still dictates the transition towards grazing angles
+func (s pbrSurface) dielectricFresnel(cosTheta float32) primitive.ScalarColor {
+	exact := fresnelDielectric(cosTheta, s.eta)
+	f0 := iorToFresnel0(s.eta, 1)
+
+	reflectance := primitive.ScalarColor{R: exact, G: exact, B: exact}
+	if s.specularColor != (primitive.ScalarColor{R: 1, G: 1, B: 1}) && f0 < 1 {
+		t := min(max((exact-f0)/(1-f0), 0), 1)
+		tinted := s.specularColor.MulScalar(f0)
+		tinted = primitive.ScalarColor{R: min(tinted.R, 1), G: min(tinted.G, 1), B: min(tinted.B, 1)}
+		reflectance = tinted.MulScalar(1 - t).AddScalar(t)
+	}
+
+	if s.film.factor > 0 {
+		f0Color := s.specularColor.MulScalar(f0)
+		reflectance = lerpColor(s.film.factor, reflectance, s.film.fresnel(cosTheta, 1, f0Color))
+	}
+	return reflectance.MulScalar(s.specular)
+}
+
+func (s pbrSurface) dielectricTransmission() float32 {
+	return (1 - s.metallic) * s.transmission
+}
+
+// Chooses between the specular, diffuse & transmission lobe proportional to their estimated albedo seen from wo
+func (s pbrSurface) lobeProbabilities(wo primitive.Vec3) (reflection, diffuse, transmission float32) {
+	dielectric := s.dielectricFresnel(wo.Z)
+	base := (1 - s.metallic) * (1 - dielectric.MaxComponent()) * s.baseColor.Luminance()
+
+	reflection = s.fresnel(wo.Z, dielectric).Luminance()
+	diffuse = base * (1 - s.transmission)
+	transmission = base * s.transmission
+
+	total := reflection + diffuse + transmission
+	if total <= 0 {
+		return 1, 0, 0
+	}
+	return reflection / total, diffuse / total, transmission / total
+}
+
+func lerpColor(t float32, a, b primitive.ScalarColor) primitive.ScalarColor {
+	return a.MulScalar(1 - t).Add(b.MulScalar(t))
+}
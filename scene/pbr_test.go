@@ -0,0 +1,161 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+var white = primitive.ScalarColor{R: 1, G: 1, B: 1}
+
+func createTestHit() *Hit {
+	normal := primitive.Vec3{Z: 1}
+	return &Hit{
+		GeometricNormal: normal,
+		Normal:          normal,
+		VertexNormal:    normal,
+		FrontFace:       true,
+	}
+}
+
+// Returns the view direction with the given cosine to the +Z normal
+func viewDirection(cosTheta float32) primitive.Vec3 {
+	sinTheta := float32(math.Sqrt(float64(1 - cosTheta*cosTheta)))
+	return primitive.Vec3{X: sinTheta, Z: cosTheta}
+}
+
+// Estimates the fraction of the energy arriving from wo which is reflected or transmitted (white furnace test)
+func estimateAlbedo(material Material, hit *Hit, wo primitive.Vec3, sampleCount int) float32 {
+	rng := common.NewRng()
+	rng.Reset(7, 0, 0)
+
+	var albedo primitive.ScalarColor
+	for range sampleCount {
+		sample, sampled := material.Sample(wo, hit, rng.Float32(), primitive.Vec2{X: rng.Float32(), Y: rng.Float32()})
+		if !sampled || sample.Pdf <= 0 {
+			continue
+		}
+		cosTheta := common.Abs(sample.Direction.Dot(hit.Normal))
+		albedo = albedo.Add(sample.F.MulScalar(cosTheta / sample.Pdf))
+	}
+	return albedo.MulScalar(1 / float32(sampleCount)).MaxComponent()
+}
+
+func TestPBRConservesEnergy(t *testing.T) {
+	for _, metallic := range []float32{0, 1} {
+		for _, roughness := range []float32{0, 0.05, 0.3, 0.6, 1} {
+			for _, cosTheta := range []float32{0.1, 0.5, 1} {
+				t.Run(fmt.Sprintf("metallic %.0f roughness %.2f cos %.1f", metallic, roughness, cosTheta), func(t *testing.T) {
+					material := NewPBR(white, metallic, roughness)
+
+					albedo := estimateAlbedo(material, createTestHit(), viewDirection(cosTheta), 20000)
+					// leaves some room for the variance of the estimate
+					if albedo > 1.01 {
+						t.Errorf("albedo = %f, want at most 1", albedo)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestPBRWithClearcoatConservesEnergy(t *testing.T) {
+	for _, roughness := range []float32{0, 0.1, 0.5} {
+		for _, cosTheta := range []float32{0.1, 0.5, 1} {
+			t.Run(fmt.Sprintf("coat roughness %.1f cos %.1f", roughness, cosTheta), func(t *testing.T) {
+				material := NewPBR(white, 0, 0.5)
+				material.SetClearcoat(1, nil, roughness, nil)
+
+				albedo := estimateAlbedo(material, createTestHit(), viewDirection(cosTheta), 20000)
+				if albedo > 1.01 {
+					t.Errorf("albedo = %f, want at most 1", albedo)
+				}
+			})
+		}
+	}
+}
+
+func TestPBRSampleMatchesEvalAndPdf(t *testing.T) {
+	tests := []struct {
+		name     string
+		material func() *PBR
+	}{
+		{"dielectric", func() *PBR { return NewPBR(white, 0, 0.4) }},
+		{"conductor", func() *PBR { return NewPBR(white, 1, 0.2) }},
+		{"rough transmission", func() *PBR {
+			pbr := NewPBR(white, 0, 0.5)
+			pbr.SetTransmission(1, nil)
+			return pbr
+		}},
+		{"sheen & clear coat", func() *PBR {
+			pbr := NewPBR(primitive.ScalarColor{R: 0.5, G: 0.2, B: 0.1}, 0, 0.7)
+			pbr.SetSheen(white, nil, 0.5, nil)
+			pbr.SetClearcoat(1, nil, 0.3, nil)
+			return pbr
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			material := tt.material()
+			hit := createTestHit()
+			wo := viewDirection(0.6)
+
+			rng := common.NewRng()
+			for i := range 1000 {
+				rng.Reset(11, 0, uint64(i))
+				sample, sampled := material.Sample(wo, hit, rng.Float32(), primitive.Vec2{X: rng.Float32(), Y: rng.Float32()})
+				if !sampled || sample.Flags.IsSpecular() {
+					continue
+				}
+
+				pdf := material.Pdf(wo, sample.Direction, hit)
+				if !approxEqualRelative(sample.Pdf, pdf) {
+					t.Fatalf("sample %d: density %f, Pdf returns %f", i, sample.Pdf, pdf)
+				}
+				value := material.Eval(wo, sample.Direction, hit)
+				if !approxEqualRelative(sample.F.R, value.R) || !approxEqualRelative(sample.F.B, value.B) {
+					t.Fatalf("sample %d: value %+v, Eval returns %+v", i, sample.F, value)
+				}
+			}
+		})
+	}
+}
+
+// Estimates the integral of the density over the reflected directions, which is only missing the few microfacet
+// reflections ending up below the horizon
+func TestPBRPdfIntegratesToOne(t *testing.T) {
+	for _, roughness := range []float32{0.3, 0.6, 1} {
+		t.Run(fmt.Sprintf("roughness %.1f", roughness), func(t *testing.T) {
+			material := NewPBR(white, 0, roughness)
+			hit := createTestHit()
+			wo := viewDirection(0.7)
+
+			const sampleCount = 200000
+			rng := common.NewRng()
+			rng.Reset(13, 0, 0)
+
+			var integral float64
+			for range sampleCount {
+				// the material doesn't transmit, hence only the upper hemisphere carries density
+				wi := primitive.SampleCosineHemisphere(primitive.Vec2{X: rng.Float32(), Y: rng.Float32()})
+				if wi.Z <= 0 {
+					continue
+				}
+				integral += float64(material.Pdf(wo, wi, hit) / primitive.CosineHemispherePdf(wi.Z))
+			}
+			integral /= sampleCount
+
+			if math.Abs(integral-1) > 0.03 {
+				t.Errorf("density integrates to %f, want 1", integral)
+			}
+		})
+	}
+}
+
+func approxEqualRelative(a, b float32) bool {
+	return common.Abs(a-b) <= 1e-4*max(common.Abs(a), common.Abs(b), 1)
+}
@@ -0,0 +1,153 @@
+package scene
+
+import (
+	"math"
+	"sync"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// The Charlie distribution degenerates numerically for smaller roughness values
+const min_sheen_roughness float32 = 0.07
+
+// Entries of the directional albedo table along the cosine of the viewing angle & the roughness
+const sheen_albedo_resolution = 32
+
+// Quadrature points per dimension used to integrate a single entry of the albedo table
+const sheen_albedo_quadrature = 64
+
+// Directional albedo of the uncolored sheen lobe, which is tabulated on first use
+var sheenAlbedo struct {
+	once  sync.Once
+	table []float32
+}
+
+// Retro-reflective layer of fabrics using the Charlie distribution (Estevez & Kulla, "Production Friendly Microfacet
+// Sheen BRDF") & the visibility term of Neubelt & Pettineo. All directions are expected in the local shading frame.
+type sheenLayer struct {
+	color primitive.ScalarColor
+	alpha float32
+}
+
+func newSheenLayer(color primitive.ScalarColor, roughness float32) sheenLayer {
+	roughness = max(roughness, min_sheen_roughness)
+	return sheenLayer{
+		color: color,
+		alpha: roughness * roughness,
+	}
+}
+
+// Returns the fraction of energy reflected by the sheen towards wo, which is also the probability of sampling it
+func (s sheenLayer) weight(wo primitive.Vec3) float32 {
+	if wo.Z <= 0 || s.color.MaxComponent() <= 0 {
+		return 0
+	}
+	return min(1, s.color.MaxComponent()*sheenDirectionalAlbedo(wo.Z, s.alpha))
+}
+
+// Returns the factor scaling the base below the sheen, which only receives the energy the sheen leaves in both
+// directions. Using the weaker of both keeps the scaling symmetric in wo & wi, as specified by KHR_materials_sheen.
+func (s sheenLayer) baseScaling(wo, wi primitive.Vec3) float32 {
+	return 1 - max(s.weight(wo), s.weight(wi))
+}
+
+func (s sheenLayer) eval(wo, wi primitive.Vec3) primitive.ScalarColor {
+	if wo.Z <= 0 || wi.Z <= 0 || s.color.MaxComponent() <= 0 {
+		return primitive.BLACK
+	}
+
+	wm := wo.Add(wi)
+	if wm.LengthSquared() == 0 {
+		return primitive.BLACK
+	}
+	wm = wm.Normalize()
+
+	return s.color.MulScalar(charlieD(wm.Z, s.alpha) * sheenVisibility(wo.Z, wi.Z))
+}
+
+func (s sheenLayer) pdf(wo, wi primitive.Vec3) float32 {
+	if wo.Z <= 0 || wi.Z <= 0 {
+		return 0
+	}
+	return primitive.CosineHemispherePdf(wi.Z)
+}
+
+// The lobe is broad enough to be sampled by cosine weighted directions, independent of the roughness
+func (s sheenLayer) sample(frame primitive.Frame, wo primitive.Vec3, u primitive.Vec2) (BSDFSample, bool) {
+	wiLocal := primitive.SampleCosineHemisphere(u)
+	if frame.ToLocal(wo).Z <= 0 || wiLocal.Z <= 0 {
+		return BSDFSample{}, false
+	}
+
+	return BSDFSample{
+		Direction: frame.FromLocal(wiLocal),
+		Flags:     LOBE_REFLECTION | LOBE_GLOSSY,
+	}, true
+}
+
+func charlieD(cosThetaM, alpha float32) float32 {
+	invAlpha := 1 / alpha
+	sin2ThetaM := max(0, 1-cosThetaM*cosThetaM)
+	return (2 + invAlpha) * float32(math.Pow(float64(sin2ThetaM), float64(invAlpha/2))) / (2 * math.Pi)
+}
+
+func sheenVisibility(cosThetaO, cosThetaI float32) float32 {
+	return 1 / (4 * (cosThetaI + cosThetaO - cosThetaI*cosThetaO))
+}
+
+// Looks up the albedo of the sheen seen from the given angle, bilinearly interpolated from the table
+func sheenDirectionalAlbedo(cosTheta, alpha float32) float32 {
+	sheenAlbedo.once.Do(tabulateSheenAlbedo)
+
+	// the viewing angles are tabulated at the cell centers, as grazing angles don't reflect anything
+	last := float32(sheen_albedo_resolution - 1)
+	x := min(max(cosTheta*sheen_albedo_resolution-0.5, 0), last)
+	y := min(max(float32(math.Sqrt(float64(alpha))), 0), 1) * last
+
+	x0 := min(int(x), sheen_albedo_resolution-2)
+	y0 := min(int(y), sheen_albedo_resolution-2)
+	tx := x - float32(x0)
+	ty := y - float32(y0)
+
+	entry := func(x, y int) float32 {
+		return sheenAlbedo.table[y*sheen_albedo_resolution+x]
+	}
+	top := entry(x0, y0)*(1-tx) + entry(x0+1, y0)*tx
+	bottom := entry(x0, y0+1)*(1-tx) + entry(x0+1, y0+1)*tx
+	return top*(1-ty) + bottom*ty
+}
+
+// Integrates the uncolored sheen lobe over the hemisphere for each viewing angle & roughness, using the midpoint
+// rule over the cosine of the polar & the azimuthal angle of the incident direction. The lobe is symmetric to the
+// plane spanned by the normal & wo, hence only half of the azimuthal angles are integrated.
+func tabulateSheenAlbedo() {
+	sheenAlbedo.table = make([]float32, sheen_albedo_resolution*sheen_albedo_resolution)
+
+	last := float32(sheen_albedo_resolution - 1)
+	step := 1 / float32(sheen_albedo_quadrature)
+	for y := range sheen_albedo_resolution {
+		lobe := newSheenLayer(primitive.ScalarColor{R: 1, G: 1, B: 1}, float32(y)/last)
+
+		for x := range sheen_albedo_resolution {
+			cosThetaO := (float32(x) + 0.5) / sheen_albedo_resolution
+			wo := primitive.Vec3{X: float32(math.Sqrt(float64(1 - cosThetaO*cosThetaO))), Z: cosThetaO}
+
+			var albedo float32
+			for i := range sheen_albedo_quadrature {
+				cosThetaI := (float32(i) + 0.5) * step
+				sinThetaI := float32(math.Sqrt(float64(1 - cosThetaI*cosThetaI)))
+				for j := range sheen_albedo_quadrature {
+					phi := (float32(j) + 0.5) * step * math.Pi
+					wi := primitive.Vec3{
+						X: sinThetaI * float32(math.Cos(float64(phi))),
+						Y: sinThetaI * float32(math.Sin(float64(phi))),
+						Z: cosThetaI,
+					}
+					albedo += lobe.eval(wo, wi).R * cosThetaI
+				}
+			}
+
+			sheenAlbedo.table[y*sheen_albedo_resolution+x] = min(1, albedo*step*step*2*math.Pi)
+		}
+	}
+}
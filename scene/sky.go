@@ -0,0 +1,178 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Resolution of the equirectangular image the sky is tabulated into
+const sky_width = 512
+const sky_height = 256
+
+// Angular radius of the sun disk as seen from the earth in radians
+const sun_angular_radius float32 = 0.004654
+
+// Luminance of the sun disk outside of the atmosphere in kcd/m², the unit of the sky model
+const extraterrestrial_sun_luminance = 1.96e6
+
+// Converts luminance in kcd/m² into radiance of the renderer, such that a white diffuse surface under a sun in the
+// zenith reflects a radiance of about one
+const sky_radiance_scale = 0.025
+
+// Wavelengths in micrometers the attenuation of the sunlight is evaluated at, one per color channel
+var sunWavelengths = [3]float64{0.68, 0.55, 0.44}
+
+// Returns the direction towards a sun at the given elevation above the horizon & azimuth, both in radians. An
+// azimuth of zero points towards -Z, positive angles turn towards +X.
+func SunDirection(elevation, azimuth float32) primitive.Vec3 {
+	cosElevation := math.Cos(float64(elevation))
+	return primitive.Vec3{
+		X: float32(cosElevation * math.Sin(float64(azimuth))),
+		Y: float32(math.Sin(float64(elevation))),
+		Z: float32(-cosElevation * math.Cos(float64(azimuth))),
+	}
+}
+
+// Creates the daylight of a clear sky (Preetham et al., "A Practical Analytic Model for Daylight") lit by a sun in
+// the given direction. The sky is tabulated into an environment, while the sun disk is a separate light to be
+// sampled directly. The ground below the horizon reflects the light of both diffusely by the given albedo.
+func NewSky(sunDirection primitive.Vec3, turbidity, groundAlbedo, intensity float32) (*Environment, *Sun) {
+	sunDirection = sunDirection.Normalize()
+	// the model isn't defined for a sun below the horizon
+	thetaSun := min(math.Acos(float64(min(max(sunDirection.Y, -1), 1))), math.Pi/2)
+
+	sunRadiance := primitive.BLACK
+	if sunDirection.Y > 0 {
+		transmittance := sunTransmittance(thetaSun, float64(turbidity))
+		sunRadiance = primitive.ScalarColor{
+			R: float32(transmittance[0]),
+			G: float32(transmittance[1]),
+			B: float32(transmittance[2]),
+		}.MulScalar(extraterrestrial_sun_luminance * sky_radiance_scale * intensity)
+	}
+	sun := NewSun(sunDirection, sunRadiance, sun_angular_radius)
+
+	model := newPreethamSky(thetaSun, float64(turbidity))
+	texels := make([]primitive.ScalarColor, sky_width*sky_height)
+	// irradiance of a horizontal plane, which lights the ground, starting with the sun
+	irradiance := sunRadiance.MulScalar(float32(2*math.Pi*(1-math.Cos(float64(sun_angular_radius)))) * sunDirection.Y)
+
+	// the upper half of the image covers the sky
+	for y := range sky_height / 2 {
+		v := (float32(y) + 0.5) / sky_height
+		solidAngle := float32(2 * math.Pi * math.Pi * math.Sin(math.Pi*float64(v)) / (sky_width * sky_height))
+
+		for x := range sky_width {
+			direction := equirectangularDirection(primitive.Vec2{X: (float32(x) + 0.5) / sky_width, Y: v})
+			cosGamma := float64(min(max(direction.Dot(sunDirection), -1), 1))
+			radiance := model.radiance(float64(direction.Y), math.Acos(cosGamma)).MulScalar(sky_radiance_scale * intensity)
+
+			texels[y*sky_width+x] = radiance
+			irradiance = irradiance.Add(radiance.MulScalar(direction.Y * solidAngle))
+		}
+	}
+
+	ground := irradiance.MulScalar(groundAlbedo / math.Pi)
+	for y := sky_height / 2; y < sky_height; y++ {
+		for x := range sky_width {
+			texels[y*sky_width+x] = ground
+		}
+	}
+
+	return NewEnvironment(sky_width, sky_height, texels, 0, 1), sun
+}
+
+// Perez distribution of the luminance & chromaticity of the sky, scaled to the values at the zenith
+type preethamSky struct {
+	thetaSun float64
+	// coefficients of the luminance Y & the chromaticities x, y
+	perez [3][5]float64
+	// values at the zenith
+	zenith [3]float64
+}
+
+func newPreethamSky(thetaSun, turbidity float64) preethamSky {
+	t := turbidity
+	perez := [3][5]float64{
+		{0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251, 0.1206*t - 2.5771, -0.0670*t + 0.3703},
+		{-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125, -0.0641*t - 0.8989, -0.0033*t + 0.0452},
+		{-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102, -0.0441*t - 1.6537, -0.0109*t + 0.0529},
+	}
+
+	chi := (4.0/9.0 - t/120) * (math.Pi - 2*thetaSun)
+	zenithLuminance := (4.0453*t-4.9710)*math.Tan(chi) - 0.2155*t + 2.4192
+
+	theta := [4]float64{thetaSun * thetaSun * thetaSun, thetaSun * thetaSun, thetaSun, 1}
+	chromaticity := func(coefficients [3][4]float64) float64 {
+		var value float64
+		for i, factor := range [3]float64{t * t, t, 1} {
+			for j := range theta {
+				value += factor * coefficients[i][j] * theta[j]
+			}
+		}
+		return value
+	}
+	zenithX := chromaticity([3][4]float64{
+		{0.00166, -0.00375, 0.00209, 0},
+		{-0.02903, 0.06377, -0.03202, 0.00394},
+		{0.11693, -0.21196, 0.06052, 0.25886},
+	})
+	zenithY := chromaticity([3][4]float64{
+		{0.00275, -0.00610, 0.00317, 0},
+		{-0.04214, 0.08970, -0.04153, 0.00516},
+		{0.15346, -0.26756, 0.06670, 0.26688},
+	})
+
+	return preethamSky{
+		thetaSun: thetaSun,
+		perez:    perez,
+		zenith:   [3]float64{max(zenithLuminance, 0), zenithX, zenithY},
+	}
+}
+
+// Returns the radiance in kcd/m² of the sky in a direction with the given cosine to the zenith & angle to the sun
+func (s preethamSky) radiance(cosTheta, gamma float64) primitive.ScalarColor {
+	var values [3]float64
+	for i, coefficients := range s.perez {
+		values[i] = s.zenith[i] * perezFunction(coefficients, cosTheta, gamma) / perezFunction(coefficients, 1, s.thetaSun)
+	}
+	luminance, x, y := values[0], values[1], values[2]
+	if luminance <= 0 || y <= 0 {
+		return primitive.BLACK
+	}
+
+	// chromaticity to CIE XYZ & further to linear sRGB
+	cieX := x * luminance / y
+	cieZ := (1 - x - y) * luminance / y
+	return primitive.ScalarColor{
+		R: float32(max(0, 3.2404542*cieX-1.5371385*luminance-0.4985314*cieZ)),
+		G: float32(max(0, -0.9692660*cieX+1.8760108*luminance+0.0415560*cieZ)),
+		B: float32(max(0, 0.0556434*cieX-0.2040259*luminance+1.0572252*cieZ)),
+	}
+}
+
+func perezFunction(coefficients [5]float64, cosTheta, gamma float64) float64 {
+	a, b, c, d, e := coefficients[0], coefficients[1], coefficients[2], coefficients[3], coefficients[4]
+	cosGamma := math.Cos(gamma)
+	return (1 + a*math.Exp(b/max(cosTheta, 1e-3))) * (1 + c*math.Exp(d*gamma) + e*cosGamma*cosGamma)
+}
+
+// Returns the fraction of the sunlight passing through the atmosphere per color channel, attenuated by Rayleigh
+// scattering on molecules & by aerosols (Ångström's formula). Absorption by ozone & water vapor is neglected.
+func sunTransmittance(thetaSun, turbidity float64) [3]float64 {
+	// relative length of the path through the atmosphere (Kasten)
+	degrees := thetaSun * 180 / math.Pi
+	opticalMass := 1 / (math.Cos(thetaSun) + 0.15*math.Pow(93.885-degrees, -1.253))
+
+	beta := 0.04608*turbidity - 0.04586
+	const alpha = 1.3
+
+	var transmittance [3]float64
+	for i, wavelength := range sunWavelengths {
+		rayleigh := math.Exp(-0.008735 * math.Pow(wavelength, -4.08) * opticalMass)
+		aerosol := math.Exp(-beta * math.Pow(wavelength, -alpha) * opticalMass)
+		transmittance[i] = rayleigh * aerosol
+	}
+	return transmittance
+}
@@ -0,0 +1,72 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+var _ InfiniteLight = (*Sun)(nil)
+
+// Disk of uniform radiance at an infinite distance, sampled uniformly within the cone it subtends
+type Sun struct {
+	frame    primitive.Frame
+	radiance primitive.ScalarColor
+	// complement of the cosine of the angular radius, which is tiny for the sun
+	oneMinusCosMaxTheta float32
+}
+
+// Expects the normalized direction towards the center of the disk & its angular radius in radians
+func NewSun(direction primitive.Vec3, radiance primitive.ScalarColor, angularRadius float32) *Sun {
+	// 1 - cos(x) = 2 sin²(x/2) stays precise for small angles
+	halfSin := math.Sin(float64(angularRadius) / 2)
+
+	return &Sun{
+		frame:               primitive.NewFrame(direction),
+		radiance:            radiance,
+		oneMinusCosMaxTheta: float32(2 * halfSin * halfSin),
+	}
+}
+
+func (s *Sun) Radiance(direction primitive.Vec3) primitive.ScalarColor {
+	if !s.covers(direction) {
+		return primitive.BLACK
+	}
+	return s.radiance
+}
+
+func (s *Sun) Sample(u primitive.Vec2) (primitive.Vec3, primitive.ScalarColor, float32) {
+	oneMinusCosTheta := u.X * s.oneMinusCosMaxTheta
+	cosTheta := 1 - oneMinusCosTheta
+	sinTheta := float32(math.Sqrt(float64(max(0, oneMinusCosTheta*(1+cosTheta)))))
+	phi := 2 * math.Pi * float64(u.Y)
+
+	local := primitive.Vec3{
+		X: sinTheta * float32(math.Cos(phi)),
+		Y: sinTheta * float32(math.Sin(phi)),
+		Z: cosTheta,
+	}
+	direction := s.frame.FromLocal(local)
+	// rounding may push samples at the rim just outside of the disk, where they carry no light
+	if !s.covers(direction) {
+		return primitive.Vec3{}, primitive.BLACK, 0
+	}
+	return direction, s.radiance, s.conePdf()
+}
+
+func (s *Sun) Pdf(direction primitive.Vec3) float32 {
+	if !s.covers(direction) {
+		return 0
+	}
+	return s.conePdf()
+}
+
+// Checks whether the normalized direction falls onto the disk. The cosine to its center is too imprecise for such a
+// small cone, hence 1 - cos(θ) is derived from the distance to the center direction instead.
+func (s *Sun) covers(direction primitive.Vec3) bool {
+	return direction.Sub(s.frame.Z).LengthSquared()/2 <= s.oneMinusCosMaxTheta
+}
+
+func (s *Sun) conePdf() float32 {
+	return 1 / (2 * math.Pi * s.oneMinusCosMaxTheta)
+}
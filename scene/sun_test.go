@@ -0,0 +1,66 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+func TestSunSampleMatchesRadianceAndPdf(t *testing.T) {
+	directions := []primitive.Vec3{
+		{Y: 1},
+		SunDirection(0.7, 0.3),
+		SunDirection(0.05, 2),
+		primitive.Vec3{X: 1, Y: 2, Z: -3}.Normalize(),
+	}
+	radiance := primitive.ScalarColor{R: 1, G: 0.9, B: 0.8}
+
+	for _, direction := range directions {
+		sun := NewSun(direction, radiance, sun_angular_radius)
+		rng := common.NewRng()
+		rng.Reset(17, 0, 0)
+
+		rejected := 0
+		for range 100000 {
+			wi, incident, pdf := sun.Sample(primitive.Vec2{X: rng.Float32(), Y: rng.Float32()})
+			if pdf == 0 {
+				rejected++
+				continue
+			}
+
+			if incident != sun.Radiance(wi) || pdf != sun.Pdf(wi) {
+				t.Fatalf("sun %v: sample towards %v has radiance %+v & density %f, Radiance & Pdf return %+v & %f",
+					direction, wi, incident, pdf, sun.Radiance(wi), sun.Pdf(wi))
+			}
+		}
+		// samples are only discarded by rounding at the rim
+		if rejected > 10 {
+			t.Errorf("sun %v: %d samples discarded, want hardly any", direction, rejected)
+		}
+	}
+}
+
+func TestSunCoversOnlyItsDisk(t *testing.T) {
+	sun := NewSun(primitive.Vec3{Y: 1}, primitive.ScalarColor{R: 1, G: 1, B: 1}, 0.01)
+
+	tests := []struct {
+		angle float32
+		want  bool
+	}{
+		{0, true},
+		{0.0099, true},
+		{0.0101, false},
+		{1, false},
+	}
+
+	for _, tt := range tests {
+		direction := SunDirection(1.5707964-tt.angle, 0)
+		if got := !sun.Radiance(direction).IsBlack(); got != tt.want {
+			t.Errorf("direction %f rad off the center is lit: %t, want %t", tt.angle, got, tt.want)
+		}
+		if got := sun.Pdf(direction) > 0; got != tt.want {
+			t.Errorf("direction %f rad off the center has a density: %t, want %t", tt.angle, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package scene
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+type WrapMode uint8
+
+const (
+	WRAP_REPEAT WrapMode = iota
+	WRAP_CLAMP_TO_EDGE
+	WRAP_MIRRORED_REPEAT
+)
+
+type TextureFilter uint8
+
+const (
+	FILTER_LINEAR TextureFilter = iota
+	FILTER_NEAREST
+)
+
+type texel struct {
+	color primitive.ScalarColor
+	alpha float32
+}
+
+// Image stored as linear floating point texels, sampled using normalized texture coordinates where (0,0) is the
+// top left corner of the image
+type Texture struct {
+	width, height int
+	texels        []texel
+	wrapS, wrapT  WrapMode
+	filter        TextureFilter
+}
+
+// Converts the image into linear texels, the color channels of sRGB encoded images are decoded beforehand
+func NewTexture(img image.Image, srgb bool, wrapS, wrapT WrapMode, filter TextureFilter) *Texture {
+	bounds := img.Bounds()
+	texture := &Texture{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		texels: make([]texel, bounds.Dx()*bounds.Dy()),
+		wrapS:  wrapS,
+		wrapT:  wrapT,
+		filter: filter,
+	}
+
+	decode := func(value uint16) float32 {
+		return float32(value) / math.MaxUint16
+	}
+	if srgb {
+		// decoding every possible channel value upfront is far cheaper than once per texel
+		lookup := make([]float32, math.MaxUint16+1)
+		for i := range lookup {
+			lookup[i] = srgbToLinear(float32(i) / math.MaxUint16)
+		}
+		decode = func(value uint16) float32 {
+			return lookup[value]
+		}
+	}
+
+	for y := range texture.height {
+		for x := range texture.width {
+			c := color.NRGBA64Model.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA64)
+			texture.texels[y*texture.width+x] = texel{
+				color: primitive.ScalarColor{R: decode(c.R), G: decode(c.G), B: decode(c.B)},
+				alpha: float32(c.A) / math.MaxUint16,
+			}
+		}
+	}
+
+	return texture
+}
+
+// Returns the mean color of all texels
+func (t *Texture) Average() primitive.ScalarColor {
+	sum := primitive.BLACK
+	for _, texel := range t.texels {
+		sum = sum.Add(texel.color)
+	}
+	return sum.DivScalar(float32(len(t.texels)))
+}
+
+func (t *Texture) Sample(uv primitive.Vec2) primitive.ScalarColor {
+	return t.lookup(uv).color
+}
+
+func (t *Texture) SampleAlpha(uv primitive.Vec2) float32 {
+	return t.lookup(uv).alpha
+}
+
+func (t *Texture) lookup(uv primitive.Vec2) texel {
+	if t.filter == FILTER_NEAREST {
+		x := int(math.Floor(float64(uv.X * float32(t.width))))
+		y := int(math.Floor(float64(uv.Y * float32(t.height))))
+		return t.texel(x, y)
+	}
+
+	// bilinear interpolation between the four texels surrounding the texel centers
+	fx := uv.X*float32(t.width) - 0.5
+	fy := uv.Y*float32(t.height) - 0.5
+	x0 := float32(math.Floor(float64(fx)))
+	y0 := float32(math.Floor(float64(fy)))
+	tx := fx - x0
+	ty := fy - y0
+
+	x, y := int(x0), int(y0)
+	return lerpTexel(ty,
+		lerpTexel(tx, t.texel(x, y), t.texel(x+1, y)),
+		lerpTexel(tx, t.texel(x, y+1), t.texel(x+1, y+1)),
+	)
+}
+
+func (t *Texture) texel(x, y int) texel {
+	x = t.wrapS.apply(x, t.width)
+	y = t.wrapT.apply(y, t.height)
+	return t.texels[y*t.width+x]
+}
+
+func (w WrapMode) apply(coord, size int) int {
+	switch w {
+	case WRAP_CLAMP_TO_EDGE:
+		return min(max(coord, 0), size-1)
+	case WRAP_MIRRORED_REPEAT:
+		period := 2 * size
+		coord = positiveMod(coord, period)
+		if coord >= size {
+			coord = period - 1 - coord
+		}
+		return coord
+	default:
+		return positiveMod(coord, size)
+	}
+}
+
+func positiveMod(value, modulus int) int {
+	return ((value % modulus) + modulus) % modulus
+}
+
+func lerpTexel(t float32, a, b texel) texel {
+	return texel{
+		color: a.color.MulScalar(1 - t).Add(b.color.MulScalar(t)),
+		alpha: a.alpha*(1-t) + b.alpha*t,
+	}
+}
+
+func srgbToLinear(value float32) float32 {
+	if value <= 0.04045 {
+		return value / 12.92
+	}
+	return float32(math.Pow(float64((value+0.055)/1.055), 2.4))
+}
@@ -0,0 +1,67 @@
+package scene
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Row of four texels, brightening from black to white
+func createTestImage() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 4, 1))
+	for x, value := range []uint8{0, 85, 170, 255} {
+		img.SetGray(x, 0, color.Gray{Y: value})
+	}
+	return img
+}
+
+func TestTextureSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		wrap   WrapMode
+		filter TextureFilter
+		u      float32
+		want   float32
+	}{
+		{"nearest", WRAP_REPEAT, FILTER_NEAREST, 0.3, 1.0 / 3},
+		{"nearest repeat below", WRAP_REPEAT, FILTER_NEAREST, -0.125, 1},
+		{"nearest repeat above", WRAP_REPEAT, FILTER_NEAREST, 1.125, 0},
+		{"nearest repeat two periods", WRAP_REPEAT, FILTER_NEAREST, -1.625, 1.0 / 3},
+		{"nearest clamp below", WRAP_CLAMP_TO_EDGE, FILTER_NEAREST, -0.375, 0},
+		{"nearest clamp above", WRAP_CLAMP_TO_EDGE, FILTER_NEAREST, 1.125, 1},
+		{"nearest mirrored below", WRAP_MIRRORED_REPEAT, FILTER_NEAREST, -0.375, 1.0 / 3},
+		{"nearest mirrored above", WRAP_MIRRORED_REPEAT, FILTER_NEAREST, 1.375, 2.0 / 3},
+		{"nearest mirrored second period", WRAP_MIRRORED_REPEAT, FILTER_NEAREST, 2.125, 0},
+		{"linear texel center", WRAP_REPEAT, FILTER_LINEAR, 0.375, 1.0 / 3},
+		{"linear between texels", WRAP_REPEAT, FILTER_LINEAR, 0.25, 1.0 / 6},
+		// the left edge lies halfway between the first & the texel it wraps around to
+		{"linear repeat edge", WRAP_REPEAT, FILTER_LINEAR, 0, 0.5},
+		{"linear clamp edge", WRAP_CLAMP_TO_EDGE, FILTER_LINEAR, 1, 1},
+		{"linear mirrored edge", WRAP_MIRRORED_REPEAT, FILTER_LINEAR, 0, 0},
+	}
+
+	for _, tt := range tests {
+		texture := NewTexture(createTestImage(), false, tt.wrap, WRAP_REPEAT, tt.filter)
+
+		got := texture.Sample(primitive.Vec2{X: tt.u, Y: 0.5})
+		if !approxEqualColor(got, primitive.ScalarColor{R: tt.want, G: tt.want, B: tt.want}) {
+			t.Errorf("%s: Sample(%.3f) = %+v, want %f", tt.name, tt.u, got, tt.want)
+		}
+	}
+}
+
+func TestTextureDecodesSRGB(t *testing.T) {
+	texture := NewTexture(createTestImage(), true, WRAP_REPEAT, WRAP_REPEAT, FILTER_NEAREST)
+
+	// sRGB 85/255 & 170/255 in linear space
+	for _, tt := range []struct{ u, want float32 }{{0.125, 0}, {0.375, 0.0908417}, {0.625, 0.4019778}, {0.875, 1}} {
+		if got := texture.Sample(primitive.Vec2{X: tt.u, Y: 0.5}).G; !approxEqualRelative(got, tt.want) {
+			t.Errorf("Sample(%.3f) = %f, want %f", tt.u, got, tt.want)
+		}
+	}
+	if alpha := texture.SampleAlpha(primitive.Vec2{X: 0.5, Y: 0.5}); alpha != 1 {
+		t.Errorf("alpha = %f, want 1", alpha)
+	}
+}
@@ -1,6 +1,9 @@
 package scene
 
 import (
+	"math"
+
+	"github.com/ruegerj/raytracing/common"
 	"github.com/ruegerj/raytracing/primitive"
 )
 
@@ -11,12 +14,22 @@ type Triangle struct {
 	V0, V1, V2 Vertex
 	Centroid   primitive.Vec3
 	Material   Material
+	// Only set if the material is partially transparent
+	alphaTester  AlphaTester
+	cullBackFace bool
 }
 
 type Vertex struct {
-	Point  primitive.Vec3
-	Normal primitive.Vec3
-	UV     *primitive.Vec2
+	Point   primitive.Vec3
+	Normal  primitive.Vec3
+	UV      *primitive.Vec2
+	Tangent *Tangent
+}
+
+// Direction of increasing U in texture space, the bitangent is obtained by cross(normal, direction) * handedness
+type Tangent struct {
+	Direction  primitive.Vec3
+	Handedness float32
 }
 
 func NewTriangle(v0, v1, v2 Vertex, material Material) Triangle {
@@ -30,11 +43,17 @@ func NewTriangle(v0, v1, v2 Vertex, material Material) Triangle {
 		Material: material,
 	}
 
+	if tester, ok := material.(AlphaTester); ok && tester.HasAlpha() {
+		triangle.alphaTester = tester
+	}
+	triangle.cullBackFace = CullsBackFaces(material)
+
 	return triangle
 }
 
-// Möller-Trumbore algorithm
-func (tr Triangle) Hits(r primitive.Ray) *Hit {
+// Möller-Trumbore algorithm, returns the distance along the ray to the intersection. If requested, the back faces of
+// single sided triangles, whose vertices appear clockwise along the ray, are not hit.
+func (tr Triangle) Hits(r primitive.Ray, cullBackFaces bool) (float32, bool) {
 	edge1 := tr.V1.Point.Sub(tr.V0.Point)
 	edge2 := tr.V2.Point.Sub(tr.V0.Point)
 
@@ -42,7 +61,10 @@ func (tr Triangle) Hits(r primitive.Ray) *Hit {
 	a := edge1.Dot(h)
 
 	if a > -epsilon && a < epsilon {
-		return nil // Ray is parallel to the triangle
+		return 0, false // Ray is parallel to the triangle
+	}
+	if cullBackFaces && tr.cullBackFace && a < 0 {
+		return 0, false
 	}
 
 	f := 1.0 / a
@@ -50,66 +72,159 @@ func (tr Triangle) Hits(r primitive.Ray) *Hit {
 
 	u := f * s.Dot(h)
 	if u < 0.0 || u > 1.0 {
-		return nil
+		return 0, false
 	}
 
 	q := s.Cross(edge1)
 	v := f * r.Direction().Dot(q)
 	if v < 0.0 || u+v > 1.0 {
-		return nil
+		return 0, false
 	}
 
 	t := f * edge2.Dot(q)
 	if t <= epsilon {
-		return nil // Line intersection but not a ray intersection
+		return 0, false // Line intersection but not a ray intersection
 	}
 
-	intersection := r.Origin().Add(r.Direction().MulScalar(t))
-	return &Hit{
-		Distance: t,
-		Point:    intersection,
-		Normal:   tr.V0.Normal,
-		Material: tr.Material,
+	return t, true
+}
+
+// Performs the alpha test of the material at the given distance along the ray. The stochastic decision for blended
+// materials is derived from the ray itself, such that tracing the same ray always yields the same hit.
+func (tr Triangle) IsOpaqueAt(ray primitive.Ray, dist float32) bool {
+	if tr.alphaTester == nil {
+		return true
 	}
+
+	barycentric := tr.barycentricCoordinates(ray.Point(dist))
+	return tr.alphaTester.AlphaTest(tr.interpolateUV(barycentric), alphaHash(ray, dist))
 }
 
 func (tr Triangle) CreateHitFor(ray primitive.Ray, dist float32) *Hit {
 	pointVec := ray.Point(dist)
 	barycentric := tr.barycentricCoordinates(pointVec)
+	uv := tr.interpolateUV(barycentric)
 
-	var uv *primitive.Vec2
-	if tr.V0.UV != nil && tr.V1.UV != nil && tr.V2.UV != nil {
-		uv0 := tr.V0.UV
-		uv1 := tr.V1.UV
-		uv2 := tr.V2.UV
+	normal := tr.V0.Normal.MulScalar(barycentric.X).
+		Add(tr.V1.Normal.MulScalar(barycentric.Y)).
+		Add(tr.V2.Normal.MulScalar(barycentric.Z)).
+		Normalize()
+
+	hit := &Hit{
+		Distance:        dist,
+		Point:           pointVec,
+		GeometricNormal: tr.geometricNormal(),
+		Normal:          normal,
+		VertexNormal:    normal,
+		UV:              uv,
+		FrontFace:       true,
+		Material:        tr.Material,
+	}
 
-		result := uv0.MulScalar(barycentric.X).
-			Add(uv1.MulScalar(barycentric.Y)).
-			Add(uv2.MulScalar(barycentric.Z))
+	if tr.V0.Tangent != nil && tr.V1.Tangent != nil && tr.V2.Tangent != nil {
+		tangent := tr.V0.Tangent.Direction.MulScalar(barycentric.X).
+			Add(tr.V1.Tangent.Direction.MulScalar(barycentric.Y)).
+			Add(tr.V2.Tangent.Direction.MulScalar(barycentric.Z))
+		hit.setTangentFrame(tangent, tr.V0.Tangent.Handedness)
 
-		uv = &result
+		if mapper, ok := tr.Material.(NormalMapper); ok {
+			hit.Normal = mapper.MapNormal(hit)
+		}
 	}
 
-	normal := tr.V0.Normal.MulScalar(barycentric.X).
-		Add(tr.V1.Normal.MulScalar(barycentric.Y)).
-		Add(tr.V2.Normal.MulScalar(barycentric.Z))
-
-	hitsFront := true
-	if ray.Direction().Dot(normal) > 0.0 {
-		normal = normal.MulScalar(-1)
-		hitsFront = false
+	// the side is decided by the geometric normal, as interpolated & mapped normals may lean away from the ray
+	// the whole frame is mirrored, such that normal maps evaluated on the back face yield the mirrored direction
+	side := float32(1)
+	if ray.Direction().Dot(hit.GeometricNormal) > 0.0 {
+		side = -1
+		hit.GeometricNormal = hit.GeometricNormal.Negate()
+		hit.Normal = hit.Normal.Negate()
+		hit.VertexNormal = hit.VertexNormal.Negate()
+		hit.Tangent = hit.Tangent.Negate()
+		hit.Bitangent = hit.Bitangent.Negate()
+		hit.FrontFace = false
 	}
 
+	hit.correctShadingNormal(ray.Direction().Negate())
+	hit.offsetPoint = tr.terminatorOffset(pointVec, barycentric, side)
+
+	return hit
+}
+
+func (tr Triangle) Area() float32 {
+	edge1 := tr.V1.Point.Sub(tr.V0.Point)
+	edge2 := tr.V2.Point.Sub(tr.V0.Point)
+	return edge1.Cross(edge2).Length() / 2
+}
+
+// Picks a uniformly distributed point on the triangle, the normal of the returned hit is the geometric one
+func (tr Triangle) Sample(u primitive.Vec2) *Hit {
+	barycentric := primitive.SampleUniformTriangle(u)
+	point := tr.V0.Point.MulScalar(barycentric.X).
+		Add(tr.V1.Point.MulScalar(barycentric.Y)).
+		Add(tr.V2.Point.MulScalar(barycentric.Z))
+	uv := tr.interpolateUV(barycentric)
+
+	geometricNormal := tr.geometricNormal()
 	return &Hit{
-		Distance:  dist,
-		Point:     pointVec,
-		Normal:    normal,
-		UV:        uv,
-		FrontFace: hitsFront,
-		Material:  tr.Material,
+		Point:           point,
+		GeometricNormal: geometricNormal,
+		Normal:          geometricNormal,
+		UV:              uv,
+		FrontFace:       true,
+		Material:        tr.Material,
+		offsetPoint:     point,
 	}
 }
 
+// Normal of the plane spanned by the vertices, facing the side from which they appear counter-clockwise
+func (tr Triangle) geometricNormal() primitive.Vec3 {
+	edge1 := tr.V1.Point.Sub(tr.V0.Point)
+	edge2 := tr.V2.Point.Sub(tr.V0.Point)
+	return edge1.Cross(edge2).Normalize()
+}
+
+// Lifts the point off the flat triangle onto the smooth surface implied by the vertex normals facing the given side,
+// such that the flat geometry doesn't shadow itself where the shading normals bend towards a light (Hanika,
+// "Hacking the Shadow Terminator")
+func (tr Triangle) terminatorOffset(point, barycentric primitive.Vec3, side float32) primitive.Vec3 {
+	offset := func(vertex Vertex, weight float32) primitive.Vec3 {
+		normal := vertex.Normal.MulScalar(side)
+		// only points below the tangent plane of the vertex are lifted, convex regions are left untouched
+		height := min(0, point.Sub(vertex.Point).Dot(normal))
+		return normal.MulScalar(-height * weight)
+	}
+
+	return point.
+		Add(offset(tr.V0, barycentric.X)).
+		Add(offset(tr.V1, barycentric.Y)).
+		Add(offset(tr.V2, barycentric.Z))
+}
+
+// Returns nil if the vertices have no texture coordinates
+func (tr Triangle) interpolateUV(barycentric primitive.Vec3) *primitive.Vec2 {
+	if tr.V0.UV == nil || tr.V1.UV == nil || tr.V2.UV == nil {
+		return nil
+	}
+
+	uv := tr.V0.UV.MulScalar(barycentric.X).
+		Add(tr.V1.UV.MulScalar(barycentric.Y)).
+		Add(tr.V2.UV.MulScalar(barycentric.Z))
+	return &uv
+}
+
+// Hashes the ray & the distance of the hit into a uniformly distributed value in [0, 1)
+func alphaHash(ray primitive.Ray, dist float32) float32 {
+	origin := ray.Origin()
+	direction := ray.Direction()
+
+	h := common.MixBits(uint64(math.Float32bits(origin.X)) | uint64(math.Float32bits(origin.Y))<<32)
+	h = common.MixBits(h ^ (uint64(math.Float32bits(origin.Z)) | uint64(math.Float32bits(direction.X))<<32))
+	h = common.MixBits(h ^ (uint64(math.Float32bits(direction.Y)) | uint64(math.Float32bits(direction.Z))<<32))
+	h = common.MixBits(h ^ uint64(math.Float32bits(dist)))
+	return float32(h>>40) * 0x1p-24
+}
+
 func (tr Triangle) barycentricCoordinates(p primitive.Vec3) primitive.Vec3 {
 	v0v1 := tr.V1.Point.Sub(tr.V0.Point)
 	v0v2 := tr.V2.Point.Sub(tr.V0.Point)
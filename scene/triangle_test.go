@@ -0,0 +1,164 @@
+package scene
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/ruegerj/raytracing/primitive"
+)
+
+// Triangle in the XY plane at the given depth, facing +Z & spanning the unit texture square along X & Y
+func createTestTriangle(z float32, material Material) Triangle {
+	vertex := func(x, y float32) Vertex {
+		return Vertex{
+			Point:  primitive.Vec3{X: x, Y: y, Z: z},
+			Normal: primitive.Vec3{Z: 1},
+			UV:     &primitive.Vec2{X: x, Y: y},
+		}
+	}
+	return NewTriangle(vertex(0, 0), vertex(1, 0), vertex(0, 1), material)
+}
+
+// Ray towards -Z through the given point of the XY plane
+func rayThrough(x, y float32) primitive.Ray {
+	return primitive.NewRay(primitive.Vec3{X: x, Y: y, Z: 5}, primitive.Vec3{Z: -1})
+}
+
+func TestTriangleIsOpaqueAt(t *testing.T) {
+	// alpha of the texture fades out towards +X: 1 on the left, 0.2 on the right half
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	img.SetNRGBA(1, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 51})
+	texture := NewTexture(img, true, WRAP_CLAMP_TO_EDGE, WRAP_CLAMP_TO_EDGE, FILTER_NEAREST)
+
+	material := func(alpha float32, mode AlphaMode, cutoff float32, texture *Texture) *PBR {
+		pbr := NewPBR(white, 0, 1)
+		pbr.SetAlpha(alpha, mode, cutoff)
+		pbr.SetBaseColorTexture(texture)
+		return pbr
+	}
+
+	tests := []struct {
+		name     string
+		material *PBR
+		x        float32
+		want     bool
+	}{
+		{"opaque ignores alpha", material(0, ALPHA_OPAQUE, 0.5, nil), 0.2, true},
+		{"mask above cutoff", material(0.6, ALPHA_MASK, 0.5, nil), 0.2, true},
+		{"mask at cutoff", material(0.5, ALPHA_MASK, 0.5, nil), 0.2, true},
+		{"mask below cutoff", material(0.4, ALPHA_MASK, 0.5, nil), 0.2, false},
+		{"mask by opaque texel", material(1, ALPHA_MASK, 0.5, texture), 0.2, true},
+		{"mask by transparent texel", material(1, ALPHA_MASK, 0.5, texture), 0.7, false},
+		// the factor scales the alpha of the texture
+		{"mask by scaled texel", material(0.4, ALPHA_MASK, 0.5, texture), 0.2, false},
+		{"mask by lowered cutoff", material(1, ALPHA_MASK, 0.1, texture), 0.7, true},
+		{"blend fully opaque", material(1, ALPHA_BLEND, 0.5, nil), 0.2, true},
+		{"blend fully transparent", material(0, ALPHA_BLEND, 0.5, nil), 0.2, false},
+	}
+
+	for _, tt := range tests {
+		triangle := createTestTriangle(0, tt.material)
+		ray := rayThrough(tt.x, 0.1)
+
+		dist, isHit := triangle.Hits(ray, true)
+		if !isHit {
+			t.Fatalf("%s: ray missed the triangle", tt.name)
+		}
+		if got := triangle.IsOpaqueAt(ray, dist); got != tt.want {
+			t.Errorf("%s: opaque = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTriangleIsOpaqueAtBlendsStochastically(t *testing.T) {
+	triangle := createTestTriangle(0, func() *PBR {
+		pbr := NewPBR(white, 0, 1)
+		pbr.SetAlpha(0.3, ALPHA_BLEND, 0.5)
+		return pbr
+	}())
+
+	const rayCount = 10000
+	opaque := 0
+	for i := range rayCount {
+		ray := rayThrough(0.1+0.5*float32(i)/rayCount, 0.2)
+		dist, _ := triangle.Hits(ray, true)
+
+		isOpaque := triangle.IsOpaqueAt(ray, dist)
+		if isOpaque != triangle.IsOpaqueAt(ray, dist) {
+			t.Fatalf("ray %d: alpha test differs for the same ray", i)
+		}
+		if isOpaque {
+			opaque++
+		}
+	}
+
+	if fraction := float32(opaque) / rayCount; fraction < 0.27 || fraction > 0.33 {
+		t.Errorf("%.3f of the rays hit the surface, want about its alpha of 0.3", fraction)
+	}
+}
+
+func TestBvhSkipsMaskedTriangles(t *testing.T) {
+	masked := NewPBR(white, 0, 1)
+	masked.SetAlpha(0.2, ALPHA_MASK, 0.5)
+	opaque := NewPBR(white, 0, 1)
+
+	bvh := NewBvh([]Triangle{createTestTriangle(1, masked), createTestTriangle(0, opaque)})
+	ray := rayThrough(0.2, 0.2)
+
+	if hit := bvh.Intersects(ray); hit == nil || hit.Material != opaque {
+		t.Errorf("ray hit %+v, want the opaque triangle behind the masked one", hit)
+	}
+	if dist, isHit := bvh.Occludes(ray); !isHit || dist != 5 {
+		t.Errorf("ray is occluded at %f (%t), want at 5", dist, isHit)
+	}
+}
+
+func TestTriangleHitsCullsBackFacesOfSingleSidedTriangles(t *testing.T) {
+	singleSided := NewPBR(white, 0, 1)
+	doubleSided := NewPBR(white, 0, 1)
+	doubleSided.SetDoubleSided(true)
+
+	front := rayThrough(0.2, 0.2)
+	back := primitive.NewRay(primitive.Vec3{X: 0.2, Y: 0.2, Z: -5}, primitive.Vec3{Z: 1})
+
+	tests := []struct {
+		name          string
+		material      Material
+		ray           primitive.Ray
+		cullBackFaces bool
+		want          bool
+	}{
+		{"single sided front", singleSided, front, true, true},
+		{"single sided back", singleSided, back, true, false},
+		{"single sided back without culling", singleSided, back, false, true},
+		{"double sided back", doubleSided, back, true, true},
+		// materials without a notion of sides are treated as double sided
+		{"unsided back", nil, back, true, true},
+	}
+
+	for _, tt := range tests {
+		dist, isHit := createTestTriangle(0, tt.material).Hits(tt.ray, tt.cullBackFaces)
+		if isHit != tt.want {
+			t.Errorf("%s: hit = %t, want %t", tt.name, isHit, tt.want)
+		}
+		if isHit && dist != 5 {
+			t.Errorf("%s: distance = %f, want 5", tt.name, dist)
+		}
+	}
+}
+
+func TestBvhOccludesByBackFaces(t *testing.T) {
+	singleSided := NewPBR(white, 0, 1)
+	bvh := NewBvh([]Triangle{createTestTriangle(0, singleSided)})
+	back := primitive.NewRay(primitive.Vec3{X: 0.2, Y: 0.2, Z: -5}, primitive.Vec3{Z: 1})
+
+	// camera & scatter rays pass through the back, while light arriving from behind is still blocked
+	if hit := bvh.Intersects(back); hit != nil {
+		t.Errorf("ray hit the back face at %f, want it to be culled", hit.Distance)
+	}
+	if dist, isHit := bvh.Occludes(back); !isHit || dist != 5 {
+		t.Errorf("back face occludes at %f (%t), want at 5", dist, isHit)
+	}
+}
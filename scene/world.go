@@ -3,14 +3,22 @@ package scene
 import (
 	"log"
 
+	"github.com/ruegerj/raytracing/common"
+	"github.com/ruegerj/raytracing/config"
 	"github.com/ruegerj/raytracing/primitive"
 	"github.com/schollz/progressbar/v3"
 )
 
 type World struct {
-	lights []Light
-	camera Camera
-	bvh    *Bvh
+	lights              []Light
+	emitters            []Triangle
+	emitterDistribution *common.Distribution1D
+	camera              Camera
+	bvh                 *Bvh
+	// lights surrounding the scene, such as environment maps or the sky
+	infiniteLights []InfiniteLight
+	// direction towards the sun as given by a directional light of the scene, nil if there is none
+	sunDirection *primitive.Vec3
 }
 
 func NewWorld(triangles []Triangle, lights []Light, camera Camera) *World {
@@ -19,11 +27,39 @@ func NewWorld(triangles []Triangle, lights []Light, camera Camera) *World {
 	_ = spinner.Close()
 	log.Printf("bvh node count: %d\n", len(bvh.nodes))
 
+	emitters, emitterDistribution := collectEmitters(triangles)
+	log.Printf("emissive triangle count: %d\n", len(emitters))
+
 	return &World{
-		lights: lights,
-		camera: camera,
-		bvh:    bvh,
+		lights:              lights,
+		emitters:            emitters,
+		emitterDistribution: emitterDistribution,
+		camera:              camera,
+		bvh:                 bvh,
+	}
+}
+
+// Gathers all emissive triangles, weighted by their emitted power (area x radiance)
+func collectEmitters(triangles []Triangle) ([]Triangle, *common.Distribution1D) {
+	emitters := make([]Triangle, 0)
+	weights := make([]float32, 0)
+
+	for _, tri := range triangles {
+		emitter, isEmitter := tri.Material.(Emitter)
+		if !isEmitter {
+			continue
+		}
+
+		weight := tri.Area() * emitter.Radiance().Luminance()
+		if weight <= 0 {
+			continue
+		}
+
+		emitters = append(emitters, tri)
+		weights = append(weights, weight)
 	}
+
+	return emitters, common.NewDistribution1D(weights)
 }
 
 func (w *World) Camera() Camera {
@@ -34,6 +70,63 @@ func (w *World) Lights() []Light {
 	return w.lights
 }
 
+func (w *World) InfiniteLights() []InfiniteLight {
+	return w.infiniteLights
+}
+
+func (w *World) AddInfiniteLight(light InfiniteLight) {
+	w.infiniteLights = append(w.infiniteLights, light)
+}
+
+func (w *World) SunDirection() *primitive.Vec3 {
+	return w.sunDirection
+}
+
+func (w *World) SetSunDirection(direction primitive.Vec3) {
+	w.sunDirection = &direction
+}
+
 func (w *World) Hits(r primitive.Ray) *Hit {
 	return w.bvh.Intersects(r)
 }
+
+// Picks a point on an emissive triangle proportional to the emitted power, returns it with its area density
+func (w *World) SampleEmitter(uc float32, u primitive.Vec2) (*Hit, float32) {
+	emitterIdx, pmf := w.emitterDistribution.SampleDiscrete(uc)
+	if emitterIdx < 0 {
+		return nil, 0
+	}
+
+	emitter := w.emitters[emitterIdx]
+	return emitter.Sample(u), pmf / emitter.Area()
+}
+
+// Returns the area density of SampleEmitter choosing a point on a triangle with the given material
+func (w *World) EmitterPdf(material Material) float32 {
+	emitter, isEmitter := material.(Emitter)
+	if !isEmitter || w.emitterDistribution.Total() <= 0 {
+		return 0
+	}
+
+	// the selection probability is proportional to the area, hence it cancels out with the uniform area density
+	return emitter.Radiance().Luminance() / w.emitterDistribution.Total()
+}
+
+// Checks whether any geometry lies on the straight line between origin and target
+func (w *World) IsOccluded(origin, target primitive.Vec3) bool {
+	toTarget := target.Sub(origin)
+	distance := toTarget.Length()
+	direction := toTarget.DivScalar(distance)
+
+	shadowRay := primitive.NewRay(origin.Add(direction.MulScalar(config.EPSILON)), direction)
+	hitDistance, isHit := w.bvh.Occludes(shadowRay)
+
+	return isHit && hitDistance < distance-2*config.EPSILON
+}
+
+// Checks whether any geometry lies on the ray from origin towards the given direction
+func (w *World) IsOccludedTowards(origin, direction primitive.Vec3) bool {
+	shadowRay := primitive.NewRay(origin.Add(direction.MulScalar(config.EPSILON)), direction)
+	_, isHit := w.bvh.Occludes(shadowRay)
+	return isHit
+}